@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/documents"
+)
+
+// runExtractionChecks compares the text extracted from each CV in cvs/ with
+// the .txt file of the same name. The .txt files are the goldens, so plain
+// text input is not checked here.
+func (e *evaluator) runExtractionChecks() error {
+	paths, err := filepath.Glob(filepath.Join(e.dir, "cvs", "*"))
+	if err != nil {
+		return err
+	}
+	formats := documents.NewFormatRegistry()
+	for _, path := range paths {
+		if filepath.Ext(path) == ".txt" {
+			continue
+		}
+		e.run("extract/"+filepath.Base(path), func() error { return e.checkExtraction(formats, path) })
+	}
+	return nil
+}
+
+func (e *evaluator) checkExtraction(formats *documents.FormatRegistry, path string) error {
+	ext := filepath.Ext(path)
+	if ext == ".docx" && !e.docx {
+		return skip("UNIOFFICE_LICENSE_KEY not set")
+	}
+
+	format, ok := formats.ForExtension(ext)
+	if !ok {
+		return fmt.Errorf("no extractor for %s", ext)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	text, err := format.Extractor.ExtractText(context.Background(), file)
+	if err != nil {
+		return fmt.Errorf("extracting %s: %w", path, err)
+	}
+	return e.golden(strings.TrimSuffix(path, ext)+".txt", []byte(text))
+}
+
+// golden compares got with the file at path, or rewrites the file with -update.
+func (e *evaluator) golden(path string, got []byte) error {
+	if e.update {
+		return os.WriteFile(path, got, 0644)
+	}
+	want, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s is missing; run with -update to create it", path)
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(want, got) {
+		return fmt.Errorf("%s differs:\n%s", path, diffLines(string(want), string(got)))
+	}
+	return nil
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+// diffLines points at the first line where got departs from want.
+func diffLines(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; i < max(len(wantLines), len(gotLines)); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g || i >= len(wantLines) || i >= len(gotLines) {
+			return fmt.Sprintf("line %d:\nwant %q\ngot  %q", i+1, w, g)
+		}
+	}
+	return "contents differ"
+}
+
+func indent(text string) string {
+	return "      " + strings.ReplaceAll(text, "\n", "\n      ")
+}
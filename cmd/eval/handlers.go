@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/ai"
+	"github.com/Emmanuella-codes/burnished-microservice/internal/api"
+	"github.com/Emmanuella-codes/burnished-microservice/internal/config"
+	"github.com/Emmanuella-codes/burnished-microservice/internal/llmtest"
+)
+
+// evalCase is one directory under cases/: the request to make with one of
+// the CVs in cvs/, the model's scripted replies and the expected response.
+type evalCase struct {
+	Name string `json:"-"`
+	Dir  string `json:"-"`
+
+	Mode string `json:"mode"`
+	// CV names a file in cvs/, or in the case's directory for files that
+	// are not meant to be read, such as a damaged PDF.
+	CV string `json:"cv"`
+	// Filename is the name the CV is uploaded as, when not its own.
+	Filename       string `json:"filename"`
+	JobDescription string `json:"jobDescription"`
+	// Stream sends the request to /process/stream and checks its result event.
+	Stream bool `json:"stream"`
+	// Fields are extra form fields, e.g. fabrication.
+	Fields map[string]string `json:"fields"`
+
+	// replies are replies/*.txt in name order, one per model call expected
+	replies []string
+}
+
+func loadCases(dir string) ([]*evalCase, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "cases", "*", "case.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var cases []*evalCase
+	for _, path := range paths {
+		c := &evalCase{Dir: filepath.Dir(path)}
+		c.Name = filepath.Base(c.Dir)
+		if err := readJSON(path, c); err != nil {
+			return nil, err
+		}
+		replyPaths, err := filepath.Glob(filepath.Join(c.Dir, "replies", "*.txt"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(replyPaths)
+		for _, replyPath := range replyPaths {
+			reply, err := os.ReadFile(replyPath)
+			if err != nil {
+				return nil, err
+			}
+			c.replies = append(c.replies, string(reply))
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// apiKey authorizes the eval's requests to the service.
+const apiKey = "eval"
+
+// runHandlerChecks serves the API on an httptest server, its models all
+// pointed at one fake DeepSeek server that each case scripts in turn.
+func (e *evaluator) runHandlerChecks(cases []*evalCase) error {
+	fake := llmtest.NewServer(llmtest.Script())
+	defer fake.Close()
+
+	cfg := &config.Config{
+		Port:              "0",
+		DeepSeekAPIKey:    "eval",
+		DeepSeekBaseURL:   fake.URL,
+		LLMProvider:       config.ProviderDeepSeek,
+		MaxFileSize:       10 * 1024 * 1024,
+		ModelRoutes:       make(map[string][]config.ModelRoute),
+		MaxCVTokens:       60000,
+		FabricationPolicy: "warn",
+		CacheBackend:      "off",
+		JobWorkers:        1,
+		JobQueueSize:      10,
+		JobTTL:            time.Hour,
+		FormatTimeout:     time.Minute,
+		RoastTimeout:      time.Minute,
+		LetterTimeout:     time.Minute,
+
+		WebhookMaxAttempts: 1,
+	}
+	for _, mode := range config.RoutedModes {
+		cfg.ModelRoutes[mode] = []config.ModelRoute{{Provider: config.ProviderDeepSeek, Model: "deepseek-chat"}}
+	}
+	os.Setenv("BURNISHED_WEB_API_KEY", apiKey)
+
+	models, err := ai.NewRouter(cfg)
+	if err != nil {
+		return fmt.Errorf("creating models: %w", err)
+	}
+	prompts, err := ai.NewPromptStore("")
+	if err != nil {
+		return fmt.Errorf("loading prompts: %w", err)
+	}
+	service := httptest.NewServer(api.NewServer(cfg, models, prompts).Handler())
+	defer service.Close()
+
+	for i, c := range cases {
+		e.run("handler/"+c.Name, func() error {
+			if filepath.Ext(c.CV) == ".docx" && !e.docx {
+				return skip("UNIOFFICE_LICENSE_KEY not set")
+			}
+			replies := make([]llmtest.Reply, len(c.replies))
+			for j, reply := range c.replies {
+				replies[j] = llmtest.Text(reply)
+			}
+			fake.Reset(llmtest.Script(replies...))
+			return e.checkHandler(service.URL, fake, c, i)
+		})
+	}
+	return nil
+}
+
+// checkHandler posts the case and compares the normalized response with
+// response.json. It also fails when the service made a different number of
+// model calls than the case scripts.
+func (e *evaluator) checkHandler(serviceURL string, fake *llmtest.Server, c *evalCase, index int) error {
+	cvPath := filepath.Join(c.Dir, c.CV)
+	if _, err := os.Stat(cvPath); err != nil {
+		cvPath = filepath.Join(e.dir, "cvs", c.CV)
+	}
+	body, contentType, err := c.form(cvPath)
+	if err != nil {
+		return err
+	}
+	path := "/api/v1/process"
+	if c.Stream {
+		path += "/stream"
+	}
+	req, err := http.NewRequest(http.MethodPost, serviceURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	// each case is its own client, so the per-IP rate limit never trips
+	req.Header.Set("X-Forwarded-For", fmt.Sprintf("10.0.%d.%d", index/250, index%250+1))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var payload []byte
+	if c.Stream {
+		payload, err = resultEvent(resp.Body)
+	} else {
+		payload, err = io.ReadAll(resp.Body)
+	}
+	if err != nil {
+		return err
+	}
+
+	got, err := normalizeResponse(resp.StatusCode, payload)
+	if err != nil {
+		return err
+	}
+	if err := e.golden(filepath.Join(c.Dir, "response.json"), got); err != nil {
+		return err
+	}
+	if calls := len(fake.Requests()); calls != len(c.replies) {
+		return fmt.Errorf("made %d model calls, the case scripts %d", calls, len(c.replies))
+	}
+	return nil
+}
+
+func (c *evalCase) form(cvPath string) (io.Reader, string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("mode", c.Mode)
+	if c.JobDescription != "" {
+		form.WriteField("jobDescription", c.JobDescription)
+	}
+	for name, value := range c.Fields {
+		form.WriteField(name, value)
+	}
+
+	data, err := os.ReadFile(cvPath)
+	if err != nil {
+		return nil, "", err
+	}
+	filename := c.CV
+	if c.Filename != "" {
+		filename = c.Filename
+	}
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, "", err
+	}
+	part.Write(data)
+	if err := form.Close(); err != nil {
+		return nil, "", err
+	}
+	return &body, form.FormDataContentType(), nil
+}
+
+// resultEvent reads a /process/stream response up to its result event.
+func resultEvent(body io.Reader) ([]byte, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	event := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "event:"); ok {
+			event = strings.TrimSpace(name)
+			continue
+		}
+		if data, ok := strings.CutPrefix(line, "data:"); ok && event == "result" {
+			return []byte(strings.TrimSpace(data)), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("stream ended without a result event")
+}
+
+// normalizeResponse drops what legitimately changes between runs, the
+// document ID, prompt versions and the letter's date, and indents the rest
+// with sorted keys so goldens diff line by line.
+func normalizeResponse(status int, payload []byte) ([]byte, error) {
+	var body map[string]any
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, fmt.Errorf("response is not JSON: %w: %s", err, payload)
+	}
+	delete(body, "documentID")
+	delete(body, "prompts")
+	delete(body, "variants")
+	if letter, ok := body["letter"].(map[string]any); ok {
+		if date, ok := letter["date"].(string); ok && date != "" {
+			letter["date"] = "<date>"
+			if text, ok := body["coverLetter"].(string); ok {
+				body["coverLetter"] = strings.ReplaceAll(text, date, "<date>")
+			}
+		}
+	}
+
+	var normalized bytes.Buffer
+	encoder := json.NewEncoder(&normalized)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(map[string]any{"httpStatus": status, "body": body}); err != nil {
+		return nil, err
+	}
+	return normalized.Bytes(), nil
+}
@@ -0,0 +1,125 @@
+// Command eval regression-tests the service offline against the golden files
+// in testdata: text extraction from sample CVs and the /process handlers end
+// to end, with a fake DeepSeek server giving scripted model replies. Unit
+// checks live in the packages' own tests.
+//
+//	go run ./cmd/eval [-dir cmd/eval/testdata] [-run pattern] [-update] [-allow-skips] [-v]
+//
+// -update rewrites the extracted text and response goldens from the current
+// output; review the diff before committing it. DOCX checks need
+// UNIOFFICE_LICENSE_KEY; without it they are skipped and the run fails
+// unless -allow-skips is set.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"regexp"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/documents"
+	"github.com/Emmanuella-codes/burnished-microservice/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errSkipped marks a check that could not run here, e.g. for lack of a license.
+var errSkipped = errors.New("skipped")
+
+func skip(reason string) error {
+	return fmt.Errorf("%w: %s", errSkipped, reason)
+}
+
+// evaluator runs checks and tallies their outcomes.
+type evaluator struct {
+	dir    string
+	update bool
+	filter *regexp.Regexp
+	docx   bool
+
+	passed, failed, skipped int
+}
+
+func (e *evaluator) run(name string, check func() error) {
+	if e.filter != nil && !e.filter.MatchString(name) {
+		return
+	}
+	err := check()
+	switch {
+	case err == nil:
+		e.passed++
+		fmt.Printf("ok    %s\n", name)
+	case errors.Is(err, errSkipped):
+		e.skipped++
+		fmt.Printf("SKIP  %s (%v)\n", name, err)
+	default:
+		e.failed++
+		fmt.Printf("FAIL  %s\n%s\n", name, indent(err.Error()))
+	}
+}
+
+func main() {
+	dir := flag.String("dir", "cmd/eval/testdata", "directory of fixtures and goldens")
+	pattern := flag.String("run", "", "only run checks whose name matches this regexp")
+	update := flag.Bool("update", false, "rewrite goldens from the current output")
+	allowSkips := flag.Bool("allow-skips", false, "pass even when checks are skipped, e.g. DOCX checks without UNIOFFICE_LICENSE_KEY")
+	verbose := flag.Bool("v", false, "show service and model logs")
+	flag.Parse()
+
+	// the service logs every step; keep the report readable unless asked
+	var logs io.Writer = io.Discard
+	if *verbose {
+		logs = os.Stderr
+	}
+	log.SetOutput(logs)
+	utils.Logger = slog.New(slog.NewTextHandler(logs, nil))
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = logs
+
+	e := &evaluator{dir: *dir, update: *update}
+	if *pattern != "" {
+		filter, err := regexp.Compile(*pattern)
+		if err != nil {
+			log.SetOutput(os.Stderr)
+			log.Fatalf("Invalid -run pattern: %v", err)
+		}
+		e.filter = filter
+	}
+	if key := os.Getenv("UNIOFFICE_LICENSE_KEY"); key != "" {
+		if err := documents.SetLicenseKey(key); err != nil {
+			log.SetOutput(os.Stderr)
+			log.Fatalf("Failed to activate DOCX support: %v", err)
+		}
+		e.docx = true
+	}
+
+	if err := e.runAll(); err != nil {
+		fmt.Fprintf(os.Stderr, "eval: %v\n", err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("\n%d passed, %d failed, %d skipped\n", e.passed, e.failed, e.skipped)
+	if e.failed > 0 {
+		os.Exit(1)
+	}
+	// a skipped check has not passed, so it must not look like one did
+	if e.skipped > 0 && !*allowSkips {
+		fmt.Fprintf(os.Stderr, "eval: %d checks skipped; set UNIOFFICE_LICENSE_KEY to run them or pass -allow-skips\n", e.skipped)
+		os.Exit(1)
+	}
+}
+
+func (e *evaluator) runAll() error {
+	if err := e.runExtractionChecks(); err != nil {
+		return err
+	}
+	cases, err := loadCases(e.dir)
+	if err != nil {
+		return err
+	}
+	return e.runHandlerChecks(cases)
+}
@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := NewCircuitBreaker(3, time.Minute)
+	failure := errors.New("status 503")
+
+	expect := func(step string, state CircuitState, allowed bool) {
+		t.Helper()
+		if got := b.Snapshot().State; got != state {
+			t.Fatalf("%s: state = %s, want %s", step, got, state)
+		}
+		err := b.Allow()
+		if allowed && err != nil {
+			t.Fatalf("%s: Allow = %v, want nil", step, err)
+		}
+		if !allowed && !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("%s: Allow = %v, want %v", step, err, ErrCircuitOpen)
+		}
+	}
+	// cool moves the breaker's opening back past its cooldown
+	cool := func() {
+		b.mu.Lock()
+		b.openedAt = b.openedAt.Add(-time.Minute)
+		b.mu.Unlock()
+	}
+
+	expect("new", CircuitClosed, true)
+	b.Failure(failure)
+	b.Failure(failure)
+	expect("below threshold", CircuitClosed, true)
+	b.Success()
+	b.Failure(failure)
+	b.Failure(failure)
+	expect("success resets the count", CircuitClosed, true)
+	b.Failure(failure)
+	expect("at threshold", CircuitOpen, false)
+	if got := b.Snapshot(); got.ConsecutiveFailures != 3 || got.LastError != "status 503" || got.OpenedAt == nil {
+		t.Fatalf("snapshot = %+v, want 3 failures, the last error and the opening time", got)
+	}
+
+	cool()
+	expect("after cooldown", CircuitOpen, true)
+	expect("trial in flight", CircuitHalfOpen, false)
+	b.Failure(failure)
+	expect("failed trial", CircuitOpen, false)
+
+	cool()
+	expect("second cooldown", CircuitOpen, true)
+	b.Release()
+	expect("released trial", CircuitHalfOpen, true)
+	b.Success()
+	expect("successful trial", CircuitClosed, true)
+	if got := b.Snapshot(); got.ConsecutiveFailures != 0 || got.OpenedAt != nil {
+		t.Fatalf("snapshot = %+v, want a reset breaker", got)
+	}
+}
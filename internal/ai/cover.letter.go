@@ -1,29 +1,101 @@
 package ai
 
-import "fmt"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 
-func GenerateCoverLetter(cvText, jobDescription, apiKey string) (string, error) {
+	"github.com/Emmanuella-codes/burnished-microservice/internal/dtos"
+)
+
+// coverLetterDateLayout is the date line of a business letter, e.g. "17 October 2026".
+const coverLetterDateLayout = "2 January 2006"
+
+// GenerateCoverLetter writes a cover letter for the job. With onDelta set the
+// salutation, body and sign-off are streamed as plain text while the model
+// writes them; the structured letter is returned once it is complete.
+func GenerateCoverLetter(ctx context.Context, cvText, jobDescription string, provider LLMProvider, prompts *PromptStore, onDelta func(string)) (letter *dtos.CoverLetter, err error) {
 	if cvText == "" {
-		return "", fmt.Errorf("CV text is empty")
+		return nil, fmt.Errorf("CV text is empty")
 	}
 	if jobDescription == "" {
-			return "", fmt.Errorf("job description is empty")
-	}
-	prompt := fmt.Sprintf(`Based on the following resume/CV and job description, please create a compelling cover letter.
-	The cover letter should:
-	1. Be personalized based on the candidate's experience in the CV
-	2. Address key requirements from the job description
-	3. Highlight the most relevant skills and experiences
-	4. Show enthusiasm for the role and company
-	5. Be professional but conversational in tone
-	6. Be around 300-400 words in length
-	Job Description:
-	%s
-	Candidate's CV:
-	%s
-	Please write a complete cover letter that the candidate can use or adapt.
-	Return ONLY the cover letter text, no markdown formatting, no code blocks.
-	`, jobDescription, cvText)
-
-	return  callDeepSeek(prompt, apiKey)
+			return nil, fmt.Errorf("job description is empty")
+	}
+	prompt, sent, err := prompts.render(ctx, promptCoverLetter, struct {
+		JobDescription string
+		CV             string
+	}{jobDescription, cvText})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPromptBudget(provider, "CV and job description", cvText+jobDescription, prompt, letterOutputTokens); err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	defer func() { prompts.observe(sent, started, 0, err) }()
+
+	var streamText func(string)
+	if onDelta != nil {
+		streamText = newJSONFieldStreamer(onDelta, "salutation", "paragraphs", "closing", "signature").Write
+	}
+
+	response, err := complete(ctx, provider, prompt, streamText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call AI: %w", err)
+	}
+
+	cleanedResponse := cleanMarkdownJSON(response)
+
+	letter = &dtos.CoverLetter{}
+	if err := json.Unmarshal([]byte(cleanedResponse), letter); err != nil {
+		log.Printf("Failed to unmarshal JSON. Cleaned response: %s", cleanedResponse)
+		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	}
+
+	fillCoverLetterDefaults(letter)
+	if len(letter.Paragraphs) == 0 {
+		return nil, fmt.Errorf("AI response contains no letter body")
+	}
+	return letter, nil
+}
+
+// DateCoverLetter dates the letter today, e.g. when it is reused from a cache.
+func DateCoverLetter(letter *dtos.CoverLetter) {
+	letter.Date = time.Now().Format(coverLetterDateLayout)
+}
+
+// SignCoverLetter fills the sender block from the CV's parsed header and
+// signs the letter with the candidate's name, so neither can drift from
+// the CV.
+func SignCoverLetter(letter *dtos.CoverLetter, sender dtos.Header) {
+	letter.Sender = sender
+	if name := strings.TrimSpace(sender.Fullname); name != "" {
+		letter.Signature = name
+	}
+}
+
+// fillCoverLetterDefaults dates the letter and fills the salutation and
+// closing when the model leaves them out.
+func fillCoverLetterDefaults(letter *dtos.CoverLetter) {
+	DateCoverLetter(letter)
+
+	var paragraphs []string
+	for _, paragraph := range letter.Paragraphs {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	letter.Paragraphs = paragraphs
+
+	if strings.TrimSpace(letter.Salutation) == "" {
+		letter.Salutation = "Dear Hiring Manager,"
+	}
+	if strings.TrimSpace(letter.Closing) == "" {
+		letter.Closing = "Sincerely,"
+	}
 }
@@ -1,15 +1,19 @@
 package ai
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/Emmanuella-codes/burnished-microservice/internal/dtos"
 )
 
-func ParseAndOptimizeCV(cvContent, jobDescription, apiKey string) (*dtos.Resume, error) {
+// ParseAndOptimizeCV turns the CV text into a resume optimized for the job.
+// A CV too long for the provider's token limits is parsed in section-aligned
+// parts that are merged into one resume.
+func ParseAndOptimizeCV(ctx context.Context, cvContent, jobDescription string, provider LLMProvider, prompts *PromptStore) (*dtos.Resume, error) {
 	if cvContent == "" {
 		return nil, fmt.Errorf("CV content is empty")
 	}
@@ -17,122 +21,107 @@ func ParseAndOptimizeCV(cvContent, jobDescription, apiKey string) (*dtos.Resume,
 			return nil, fmt.Errorf("job description is empty")
 	}
 
-	prompt := fmt.Sprintf(`You are an expert resume parser and ATS optimizer. Parse the resume AND optimize it for the job description in ONE step.
-	
-	SECTION DETECTION - Recognize these variations:
-	- Experience: Work History, Employment, Professional Experience, Career History
-	- Education: Academic Background, Qualifications, Academic History
-	- Skills: Technical Skills, Core Competencies, Expertise, Proficiencies
-	- Projects: Portfolio, Personal Projects, Side Projects
-	- Awards: Honors, Achievements, Recognition, Certifications
-	- Summary: Profile, About, Professional Summary, Objective
-
-	PARSING RULES:
-	1. Normalize dates to "MMM YYYY" format (use "Present" for current roles)
-	2. Extract URLs separately from display names
-	3. Extract all bullet points as array items
-	4. Initialize empty arrays for missing sections
-	5. Infer skills from entire document if no dedicated section
-
-	OPTIMIZATION RULES:
-	1. Match keywords from job description naturally (don't stuff)
-	2. Quantify achievements with metrics (%%, $, X, numbers)
-	3. Use strong action verbs: Led, Developed, Implemented, Achieved, Increased, Reduced
-	4. Tailor profile summary to match the target role
-	5. Reorder/emphasize relevant experiences and skills
-	6. Add missing but relevant skills from job description IF candidate has related experience
-	7. Enhance bullet points with impact and results
-	8. NEVER fabricate experience - only enhance existing content
-	9. Rather than just listing tasks performed, emphasize the problems solved, value created and the impact of the work done
-	10. Keep all information truthful and grounded in original resume
-
-	Here is the job description:
-	%s
-
-	Here is the Resume to parse optimize:
-	%s
-
-	OUTPUT (JSON only, no markdown):
-	{
-		"header": {
-			"fullname": "", "jobTitle": "", "location": "", "email": "",
-			"phone": "", "linkedin": "", "linkedinUrl": "", "github": "",
-			"githubUrl": "", "website": "", "websiteUrl": ""
-		},
-		"profileSummary": "Optimized 2-3 sentence summary tailored to job",
-		"skills": [{"title": "Category", "values": ["relevant skills first"]}],
-		"experiences": [{
-			"company": "", "occupation": "", "startDate": "MMM YYYY",
-			"endDate": "MMM YYYY", "location": "",
-			"desc": ["Enhanced bullets with metrics and impact"]
-		}],
-		"education": [{
-			"degree": "", "institution": "", "startDate": "MMM YYYY",
-			"endDate": "MMM YYYY", "location": "", "desc": []
-		}],
-		"projects": [{"title": "", "link": "", "subtitle": "", "desc": ["Enhanced descriptions"]}],
-		"awards": [{"title": "", "link": "", "subtitle": "", "date": "MMM YYYY", "desc": []}],
-		"sectionOrder": ["header", "profileSummary", "experiences", "education", "skills", "projects", "awards"]
-	}
-
-	Return ONLY the optimized JSON:`, jobDescription, cvContent)
-
-	response, err := callDeepSeek(prompt, apiKey)
+	// size parts against the prompt as it is for a split CV, the longer variant
+	fixedPrompt, _, err := resumePrompt(ctx, prompts, jobDescription, "", 2, 2)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call AI: %w", err)
+		return nil, err
+	}
+	fixedTokens := EstimateTokens(fixedPrompt)
+	maxChunkTokens := maxResumeChunkTokens(provider.TokenLimits(), fixedTokens)
+	if maxChunkTokens < minResumeChunkTokens {
+		// a part of the CV takes 2.5 times its size in window, counting its output
+		jobTokens := EstimateTokens(jobDescription)
+		shortfall := (minResumeChunkTokens - maxChunkTokens) * 5 / 2
+		return nil, &InputTooLargeError{What: "job description", Tokens: jobTokens, Limit: max(jobTokens-shortfall, 0)}
 	}
 
-	cleanedResponse := cleanMarkdownJSON(response)
+	cvTokens := EstimateTokens(cvContent)
+	log.Printf("CV is about %d tokens, %d fit in one %s/%s call", cvTokens, maxChunkTokens, provider.Name(), provider.Model())
+	if cvTokens <= maxChunkTokens {
+		prompt, sent, err := resumePrompt(ctx, prompts, jobDescription, cvContent, 1, 1)
+		if err != nil {
+			return nil, err
+		}
+		return parseResume(ctx, prompt, sent, provider, prompts)
+	}
+
+	chunks := splitCV(cvContent, maxChunkTokens)
+	log.Printf("Parsing long CV in %d parts", len(chunks))
+	return parseResumeChunks(ctx, chunks, jobDescription, provider, prompts)
+}
 
-	var resume dtos.Resume
-	if err := json.Unmarshal([]byte(cleanedResponse), &resume); err != nil {
-		log.Printf("Failed to unmarshal JSON. Cleaned response: %s", cleanedResponse)
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+// resumePrompt asks for the resume JSON for cvContent, which is part of parts.
+func resumePrompt(ctx context.Context, prompts *PromptStore, jobDescription, cvContent string, part, parts int) (string, *Prompt, error) {
+	return prompts.render(ctx, promptResume, struct {
+		JobDescription string
+		CV             string
+		Part, Parts    int
+	}{jobDescription, cvContent, part, parts})
+}
+
+// parseResume runs prompt, rendered from sent, and validates the resume it
+// returns, sending any problems back to the model for a bounded number of
+// repairs.
+func parseResume(ctx context.Context, prompt string, sent *Prompt, provider LLMProvider, prompts *PromptStore) (resume *dtos.Resume, err error) {
+	started, repairs := time.Now(), 0
+	defer func() { prompts.observe(sent, started, repairs, err) }()
+
+	response, err := provider.Complete(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call AI: %w", err)
 	}
 
-	ValidateAndFillMissingSections(&resume)
-	return &resume, nil
+	for attempt := 1; ; attempt++ {
+		cleanedResponse := cleanMarkdownJSON(response)
+		resume, problems, err := validateResumeJSON(cleanedResponse)
+		if err != nil {
+			return nil, err
+		}
+		if len(problems) == 0 {
+			ValidateAndFillMissingSections(resume)
+			return resume, nil
+		}
+
+		log.Printf("AI resume failed validation on attempt %d with %d problems, first: %s", attempt, len(problems), problems[0])
+		if attempt > maxRepairAttempts {
+			log.Printf("Giving up on invalid AI resume. Cleaned response: %s", cleanedResponse)
+			return nil, &ResumeValidationError{Attempts: attempt, Errors: problems}
+		}
+
+		repairPrompt, err := resumeRepairPrompt(ctx, prompts, cleanedResponse, problems)
+		if err != nil {
+			return nil, err
+		}
+		repairs++
+		response, err = provider.Complete(ctx, repairPrompt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call AI for repair: %w", err)
+		}
+	}
 }
 
-func RoastCV(cvContent, apiKey string) (string, error) {
+// RoastCV critiques the CV; with onDelta set the critique is streamed as it
+// is written.
+func RoastCV(ctx context.Context, cvContent string, provider LLMProvider, prompts *PromptStore, onDelta func(string)) (string, error) {
 	if cvContent == "" {
 		return "", fmt.Errorf("CV content is empty")
 	}
 
-	prompt := fmt.Sprintf(`You are a SAVAGE CV reviewer who has seen thousands of terrible resumes. Your job is to absolutely DEMOLISH this CV with brutal honesty. DO NOT hold back.
-		IGNORE these when roasting:
-	- Dates (employment dates, education dates, etc.)
-	- Contact details in the header (GitHub, LinkedIn, website links)
-	- Name and basic contact info
-
-	ROAST MERCILESSLY:
-	1. DESTROY weak, generic language - "responsible for", "worked on", "helped with" deserve no mercy
-	2. OBLITERATE vague achievements - if there are no numbers, percentages, or concrete results, tear it apart
-	3. SAVAGE the boring bullet points - if it sounds like a job description copy-paste, roast it hard
-	4. DEMOLISH buzzword spam - "synergy", "rockstar", "guru", "passionate" etc. Show no mercy
-	5. ANNIHILATE poor formatting - walls of text, inconsistent styling, amateur mistakes
-	6. BRUTALIZE the lack of impact - if they're just listing tasks instead of achievements, destroy them
-	7. TORCH generic summaries - "hard-working team player seeking opportunities" deserves mockery
-	8. FLAME missing specifics - technologies without context, projects without outcomes
-
-	Your tone should be:
-	- Sarcastic and cutting
-	- Brutally honest, borderline offensive (but not discriminatory)
-	- Use humor, but make it HURT
-	- Compare bad examples to what they SHOULD say
-	- Don't sugarcoat ANYTHING
-
-	Here is the CV to absolutely roast:
-	%s
-
-	Format your roast as:
-	1. Start with a savage one-liner summary of the overall CV
-	2. Section-by-section destruction with specific examples of what sucks
-	3. A "wake-up call" conclusion that's motivational but brutal
-
-	Make them FEEL the pain of their mediocre CV. No participation trophies here.`, cvContent)
-
-	return callDeepSeek(prompt, apiKey)
+	prompt, sent, err := prompts.render(ctx, promptRoast, struct{ CV string }{cvContent})
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkPromptBudget(provider, "CV", cvContent, prompt, roastOutputTokens); err != nil {
+		return "", err
+	}
+	started := time.Now()
+	feedback, err := complete(ctx, provider, prompt, onDelta)
+	if err == nil && strings.TrimSpace(feedback) == "" {
+		err = fmt.Errorf("AI returned an empty critique")
+	}
+	prompts.observe(sent, started, 0, err)
+	return feedback, err
 }
 
 func ValidateAndFillMissingSections(resume *dtos.Resume) {
@@ -160,6 +149,7 @@ func ValidateAndFillMissingSections(resume *dtos.Resume) {
 	}
 }
 
+// cleanMarkdownJSON strips the ```json fence models tend to wrap JSON in.
 func cleanMarkdownJSON(response string) string {
 	// Remove leading/trailing whitespace
 	response = strings.TrimSpace(response)
@@ -0,0 +1,86 @@
+package ai
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/dtos"
+)
+
+func TestCleanMarkdownJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"bare", `{"a": 1}`, `{"a": 1}`},
+		{"json-fence", "```json\n{\"a\": 1}\n```", `{"a": 1}`},
+		{"plain-fence", "```\n{\"a\": 1}\n```", `{"a": 1}`},
+		{"surrounding-whitespace", "\n\n  ```json\n{\"a\": 1}\n```  \n", `{"a": 1}`},
+		{"unclosed-fence", "```json\n{\"a\": 1}", `{"a": 1}`},
+		{"fence-inside-string", "{\"code\": \"```go\"}", "{\"code\": \"```go\"}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanMarkdownJSON(tt.input); got != tt.want {
+				t.Errorf("cleanMarkdownJSON(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAndFillMissingSections(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "empty",
+			input: `{"header": {"fullname": "Ada Okafor"}}`,
+			want: `{
+				"header": {"fullname": "Ada Okafor", "jobTitle": "", "email": ""},
+				"skills": [],
+				"experiences": [],
+				"education": [],
+				"projects": [],
+				"sectionOrder": ["header", "profileSummary", "experiences", "education", "skills", "projects", "awards"]
+			}`,
+		},
+		{
+			name: "keeps-order-and-sections",
+			input: `{
+				"header": {"fullname": "Ada Okafor"},
+				"skills": [{"title": "Languages", "values": ["Go"]}],
+				"awards": [{"title": "Hackathon winner"}],
+				"sectionOrder": ["header", "skills", "awards"]
+			}`,
+			want: `{
+				"header": {"fullname": "Ada Okafor", "jobTitle": "", "email": ""},
+				"skills": [{"title": "Languages", "values": ["Go"]}],
+				"experiences": [],
+				"education": [],
+				"projects": [],
+				"awards": [{"title": "Hackathon winner", "link": "", "issuer": "", "date": "", "desc": null}],
+				"sectionOrder": ["header", "skills", "awards"]
+			}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resume, want dtos.Resume
+			if err := json.Unmarshal([]byte(tt.input), &resume); err != nil {
+				t.Fatalf("parsing input: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatalf("parsing want: %v", err)
+			}
+			ValidateAndFillMissingSections(&resume)
+			got, _ := json.MarshalIndent(resume, "", "  ")
+			wantJSON, _ := json.MarshalIndent(want, "", "  ")
+			if string(got) != string(wantJSON) {
+				t.Errorf("resume differs:\ngot  %s\nwant %s", got, wantJSON)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package ai
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// jsonFieldStreamer scans a JSON document as it streams in and passes on the
+// decoded text of string values under the selected keys, including strings
+// inside arrays under those keys. This lets a structured response be shown
+// to the user while the model is still writing it.
+type jsonFieldStreamer struct {
+	fields map[string]bool
+	onText func(string)
+
+	stack     []jsonFrame
+	key       string
+	expectKey bool
+
+	inString  bool
+	isKey     bool
+	emit      bool
+	escape    bool
+	hex       []byte
+	surrogate rune
+	keyBuf    strings.Builder
+	emitted   bool
+}
+
+type jsonFrame struct {
+	object bool
+	key    string
+}
+
+func newJSONFieldStreamer(onText func(string), fields ...string) *jsonFieldStreamer {
+	s := &jsonFieldStreamer{fields: make(map[string]bool), onText: onText}
+	for _, field := range fields {
+		s.fields[field] = true
+	}
+	return s
+}
+
+// Write feeds the next piece of the document; it never fails so it can be
+// used directly as an onDelta callback.
+func (s *jsonFieldStreamer) Write(delta string) {
+	var out strings.Builder
+	for i := 0; i < len(delta); i++ {
+		c := delta[i]
+		if s.inString {
+			s.stringByte(c, &out)
+			continue
+		}
+		switch c {
+		case '{', '[':
+			s.stack = append(s.stack, jsonFrame{object: c == '{', key: s.valueKey()})
+			s.expectKey = c == '{'
+		case '}', ']':
+			if len(s.stack) > 0 {
+				s.stack = s.stack[:len(s.stack)-1]
+			}
+			s.expectKey = false
+		case ',':
+			s.expectKey = len(s.stack) > 0 && s.stack[len(s.stack)-1].object
+		case ':':
+			s.expectKey = false
+		case '"':
+			s.inString = true
+			s.isKey = s.expectKey
+			s.keyBuf.Reset()
+			s.emit = !s.isKey && s.fields[s.valueKey()]
+			if s.emit {
+				if s.emitted {
+					out.WriteString("\n\n")
+				}
+				s.emitted = true
+			}
+		}
+	}
+	if out.Len() > 0 {
+		s.onText(out.String())
+	}
+}
+
+// valueKey is the key the next value belongs to: the last object key, or the
+// key an enclosing array was stored under.
+func (s *jsonFieldStreamer) valueKey() string {
+	if len(s.stack) == 0 {
+		return ""
+	}
+	if top := s.stack[len(s.stack)-1]; !top.object {
+		return top.key
+	}
+	return s.key
+}
+
+func (s *jsonFieldStreamer) stringByte(c byte, out *strings.Builder) {
+	write := func(text string) {
+		if s.isKey {
+			s.keyBuf.WriteString(text)
+		} else if s.emit {
+			out.WriteString(text)
+		}
+	}
+
+	switch {
+	case s.hex != nil:
+		s.hex = append(s.hex, c)
+		if len(s.hex) < 4 {
+			return
+		}
+		code, err := strconv.ParseUint(string(s.hex), 16, 16)
+		s.hex = nil
+		if err != nil {
+			return
+		}
+		r := rune(code)
+		switch {
+		case utf16.IsSurrogate(r) && s.surrogate == 0:
+			s.surrogate = r
+		case s.surrogate != 0:
+			write(string(utf16.DecodeRune(s.surrogate, r)))
+			s.surrogate = 0
+		default:
+			write(string(r))
+		}
+	case s.escape:
+		s.escape = false
+		switch c {
+		case 'n':
+			write("\n")
+		case 't':
+			write("\t")
+		case 'u':
+			s.hex = make([]byte, 0, 4)
+		case 'r', 'b', 'f':
+			// not useful in streamed text
+		default:
+			write(string(rune(c)))
+		}
+	case c == '\\':
+		s.escape = true
+	case c == '"':
+		s.inString = false
+		if s.isKey {
+			s.key = s.keyBuf.String()
+		}
+	default:
+		// raw bytes, so multi-byte UTF-8 sequences pass through intact
+		write(string([]byte{c}))
+	}
+}
@@ -0,0 +1,126 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/config"
+)
+
+// Router holds the fallback chain configured for each mode.
+type Router struct {
+	chains map[string]*FallbackChain
+}
+
+// NewRouter builds a FallbackChain per mode from cfg.ModelRoutes. Identical
+// routes share one provider, and with it one circuit breaker.
+func NewRouter(cfg *config.Config) (*Router, error) {
+	r := &Router{chains: make(map[string]*FallbackChain)}
+	providers := make(map[string]LLMProvider)
+	for mode, routes := range cfg.ModelRoutes {
+		chain := &FallbackChain{}
+		for _, route := range routes {
+			key := route.String()
+			provider, ok := providers[key]
+			if !ok {
+				var err error
+				provider, err = NewProvider(cfg, route)
+				if err != nil {
+					return nil, fmt.Errorf("%s mode: %w", mode, err)
+				}
+				providers[key] = provider
+			}
+			chain.providers = append(chain.providers, provider)
+		}
+		if len(chain.providers) == 0 {
+			return nil, fmt.Errorf("%s mode has no models configured", mode)
+		}
+		r.chains[mode] = chain
+	}
+	return r, nil
+}
+
+// For returns the chain for mode, or nil when mode is not routed.
+func (r *Router) For(mode string) *FallbackChain {
+	return r.chains[mode]
+}
+
+// FallbackChain is an LLMProvider that tries its providers in order until
+// one of them answers.
+type FallbackChain struct {
+	providers []LLMProvider
+}
+
+// Providers returns the chain in the order it is tried.
+func (c *FallbackChain) Providers() []LLMProvider {
+	return c.providers
+}
+
+// Name and Model describe the primary provider.
+func (c *FallbackChain) Name() string {
+	return c.providers[0].Name()
+}
+
+func (c *FallbackChain) Model() string {
+	return c.providers[0].Model()
+}
+
+// TokenLimits is the tightest limit in the chain, so a prompt sized for it
+// fits whichever model ends up answering.
+func (c *FallbackChain) TokenLimits() TokenLimits {
+	limits := c.providers[0].TokenLimits()
+	for _, provider := range c.providers[1:] {
+		other := provider.TokenLimits()
+		limits.Context = min(limits.Context, other.Context)
+		limits.Output = min(limits.Output, other.Output)
+	}
+	return limits
+}
+
+// String lists the chain as "provider/model" pairs in order.
+func (c *FallbackChain) String() string {
+	names := make([]string, len(c.providers))
+	for i, provider := range c.providers {
+		names[i] = provider.Name() + "/" + provider.Model()
+	}
+	return strings.Join(names, " -> ")
+}
+
+func (c *FallbackChain) Complete(ctx context.Context, prompt string) (string, error) {
+	return c.Stream(ctx, prompt, nil)
+}
+
+// Stream falls back only until text has been streamed; once onDelta has seen
+// part of an answer, starting over with another model would repeat it.
+func (c *FallbackChain) Stream(ctx context.Context, prompt string, onDelta func(string)) (string, error) {
+	var errs []error
+	for i, provider := range c.providers {
+		streamed := false
+		var forward func(string)
+		if onDelta != nil {
+			forward = func(delta string) {
+				streamed = true
+				onDelta(delta)
+			}
+		}
+
+		content, err := complete(ctx, provider, prompt, forward)
+		if err == nil {
+			recordServed(ctx, provider, i > 0)
+			return content, nil
+		}
+		errs = append(errs, fmt.Errorf("%s/%s: %w", provider.Name(), provider.Model(), err))
+		if ctx.Err() != nil || streamed || i == len(c.providers)-1 {
+			break
+		}
+		next := c.providers[i+1]
+		log.Printf("%s/%s failed, falling back to %s/%s: %v", provider.Name(), provider.Model(), next.Name(), next.Model(), err)
+	}
+	if len(errs) == 1 {
+		return "", errs[0]
+	}
+	return "", fmt.Errorf("all %d models failed: %w", len(errs), errors.Join(errs...))
+}
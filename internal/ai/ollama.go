@@ -0,0 +1,178 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const (
+	ollamaBaseURL = "http://localhost:11434"
+	ollamaModel   = "llama3.1"
+)
+
+type ollamaChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []ChatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Options  struct {
+		Temperature float64 `json:"temperature"`
+		// Ollama cuts prompts to its small default window unless told otherwise
+		NumCtx int `json:"num_ctx,omitempty"`
+	} `json:"options"`
+}
+
+type ollamaChatResponse struct {
+	Message ChatMessage `json:"message"`
+	Done    bool        `json:"done"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// OllamaProvider talks to a local Ollama server, for development without a
+// hosted API key.
+type OllamaProvider struct {
+	baseURL     string
+	model       string
+	temperature float64
+	limits      TokenLimits
+	breaker     *CircuitBreaker
+}
+
+func NewOllamaProvider(baseURL, model string) *OllamaProvider {
+	if baseURL == "" {
+		baseURL = ollamaBaseURL
+	}
+	if model == "" {
+		model = ollamaModel
+	}
+	return &OllamaProvider{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		model:       model,
+		temperature: defaultTemperature,
+		limits:      ollamaTokenLimits,
+		breaker:     NewCircuitBreaker(breakerThreshold, breakerCooldown),
+	}
+}
+
+func (p *OllamaProvider) Name() string {
+	return "ollama"
+}
+
+func (p *OllamaProvider) Model() string {
+	return p.model
+}
+
+func (p *OllamaProvider) TokenLimits() TokenLimits {
+	return p.limits
+}
+
+func (p *OllamaProvider) Circuit() CircuitSnapshot {
+	return p.breaker.Snapshot()
+}
+
+func (p *OllamaProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	return p.chat(ctx, prompt, nil)
+}
+
+func (p *OllamaProvider) Stream(ctx context.Context, prompt string, onDelta func(string)) (string, error) {
+	return p.chat(ctx, prompt, onDelta)
+}
+
+// chat calls /api/chat; with onDelta set Ollama streams one JSON object per
+// line and each message fragment is passed on as it arrives.
+func (p *OllamaProvider) chat(ctx context.Context, prompt string, onDelta func(string)) (string, error) {
+	if prompt == "" {
+		return "", fmt.Errorf("prompt is empty")
+	}
+
+	requestBody := ollamaChatRequest{
+		Model:    p.model,
+		Messages: []ChatMessage{{Role: "user", Content: prompt}},
+		Stream:   onDelta != nil,
+	}
+	requestBody.Options.Temperature = p.temperature
+	requestBody.Options.NumCtx = p.limits.Context
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", fmt.Errorf("marshaling request: %w", err)
+	}
+
+	resp, err := doWithRetry(ctx, p.Name(), p.breaker, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/chat", bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if onDelta != nil && resp.StatusCode == http.StatusOK {
+		return readOllamaStream(resp.Body, onDelta)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading response: %w", err)
+	}
+
+	log.Printf("ollama response status: %d, body length: %d", resp.StatusCode, len(body))
+
+	var chatResponse ollamaChatResponse
+	if err := json.Unmarshal(body, &chatResponse); err != nil {
+		return "", fmt.Errorf("unmarshaling response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, chatResponse.Error)
+	}
+	if chatResponse.Message.Content == "" {
+		return "", fmt.Errorf("no response from ollama")
+	}
+
+	return chatResponse.Message.Content, nil
+}
+
+func readOllamaStream(body io.Reader, onDelta func(string)) (string, error) {
+	var content strings.Builder
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return "", fmt.Errorf("unmarshaling stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return "", fmt.Errorf("ollama stream failed: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			onDelta(chunk.Message.Content)
+		}
+		if chunk.Done {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("reading ollama stream: %w", err)
+	}
+
+	log.Printf("ollama stream finished, content length: %d", content.Len())
+	if content.Len() == 0 {
+		return "", fmt.Errorf("no response from ollama")
+	}
+	return content.String(), nil
+}
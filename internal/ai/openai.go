@@ -0,0 +1,244 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const (
+	openAIBaseURL = "https://api.openai.com/v1"
+	openAIModel   = "gpt-4o-mini"
+)
+
+// ChatCompletionRequest is the OpenAI-style chat completions body, which
+// DeepSeek and most hosted vendors also accept.
+type ChatCompletionRequest struct {
+	Model       string        `json:"model"`
+	Messages    []ChatMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Stream      bool          `json:"stream,omitempty"`
+}
+
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ChatCompletionResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+// chatCompletionChunk is one server-sent event of a streamed completion.
+type chatCompletionChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+// ChatPreset is the defaults of a vendor serving an OpenAI-compatible chat
+// completions API.
+type ChatPreset struct {
+	// Name identifies the vendor in logs and responses.
+	Name    string
+	BaseURL string
+	Model   string
+	Limits  TokenLimits
+}
+
+// OpenAIPreset is the hosted OpenAI API.
+var OpenAIPreset = ChatPreset{Name: "openai", BaseURL: openAIBaseURL, Model: openAIModel, Limits: openAITokenLimits}
+
+// OpenAIProvider talks to any OpenAI-compatible chat completions API.
+type OpenAIProvider struct {
+	name        string
+	endpoint    string
+	apiKey      string
+	model       string
+	temperature float64
+	limits      TokenLimits
+	breaker     *CircuitBreaker
+}
+
+// NewOpenAIProvider talks to the API of preset, or the one at baseURL and
+// with model when they are set. Without an apiKey no Authorization header
+// is sent, as local servers such as vLLM or LM Studio need none.
+func NewOpenAIProvider(preset ChatPreset, baseURL, apiKey, model string) *OpenAIProvider {
+	if baseURL == "" {
+		baseURL = preset.BaseURL
+	}
+	if model == "" {
+		model = preset.Model
+	}
+	return &OpenAIProvider{
+		name:        preset.Name,
+		endpoint:    strings.TrimSuffix(baseURL, "/") + "/chat/completions",
+		apiKey:      apiKey,
+		model:       model,
+		temperature: defaultTemperature,
+		limits:      preset.Limits,
+		breaker:     NewCircuitBreaker(breakerThreshold, breakerCooldown),
+	}
+}
+
+func (p *OpenAIProvider) Name() string {
+	return p.name
+}
+
+func (p *OpenAIProvider) Model() string {
+	return p.model
+}
+
+func (p *OpenAIProvider) TokenLimits() TokenLimits {
+	return p.limits
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	return p.complete(ctx, prompt, nil)
+}
+
+func (p *OpenAIProvider) Stream(ctx context.Context, prompt string, onDelta func(string)) (string, error) {
+	return p.complete(ctx, prompt, onDelta)
+}
+
+func (p *OpenAIProvider) Circuit() CircuitSnapshot {
+	return p.breaker.Snapshot()
+}
+
+// complete sends prompt to the endpoint. When onDelta is set the completion
+// is requested as a stream and each content delta is passed on as it arrives.
+func (p *OpenAIProvider) complete(ctx context.Context, prompt string, onDelta func(string)) (string, error) {
+	vendor := p.name
+	if prompt == "" {
+		return "", fmt.Errorf("prompt is empty")
+	}
+
+	requestBody := ChatCompletionRequest{
+		Model: p.model,
+		Messages: []ChatMessage{
+			{
+				Role:    "user",
+				Content: prompt,
+			},
+		},
+		Temperature: p.temperature,
+		MaxTokens:   p.limits.Output,
+		Stream:      onDelta != nil,
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", fmt.Errorf("marshaling request: %w", err)
+	}
+
+	log.Printf("%s request body size: %d bytes", vendor, len(jsonData))
+
+	resp, err := doWithRetry(ctx, vendor, p.breaker, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(
+			ctx,
+			"POST",
+			p.endpoint,
+			bytes.NewReader(jsonData),
+		)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if p.apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+p.apiKey)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if onDelta != nil && resp.StatusCode == http.StatusOK {
+		return readChatStream(vendor, resp.Body, onDelta)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading response: %w", err)
+	}
+
+	log.Printf(
+		"%s API response status: %d, body length: %d",
+		vendor,
+		resp.StatusCode,
+		len(body),
+	)
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("%s API error response: %s", vendor, string(body))
+		return "", fmt.Errorf(
+			"%s API returned status %d: %s",
+			vendor,
+			resp.StatusCode,
+			string(body),
+		)
+	}
+
+	var completion ChatCompletionResponse
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return "", fmt.Errorf("unmarshaling response: %w", err)
+	}
+
+	if len(completion.Choices) == 0 {
+		return "", fmt.Errorf("no response from %s API", vendor)
+	}
+
+	return completion.Choices[0].Message.Content, nil
+}
+
+// readChatStream reads "data: {chunk}" server-sent events until "data: [DONE]"
+// and returns the concatenated content.
+func readChatStream(vendor string, body io.Reader, onDelta func(string)) (string, error) {
+	var content strings.Builder
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk chatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", fmt.Errorf("unmarshaling stream chunk: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				content.WriteString(choice.Delta.Content)
+				onDelta(choice.Delta.Content)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("reading %s stream: %w", vendor, err)
+	}
+
+	log.Printf("%s API stream finished, content length: %d", vendor, content.Len())
+	if content.Len() == 0 {
+		return "", fmt.Errorf("no response from %s API", vendor)
+	}
+	return content.String(), nil
+}
@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIProviderAuthorization(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey string
+		want   string
+	}{
+		{"hosted", "sk-test", "Bearer sk-test"},
+		{"keyless local server", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get("Authorization")
+				if r.URL.Path != "/v1/chat/completions" {
+					t.Errorf("path = %s, want /v1/chat/completions", r.URL.Path)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+			}))
+			defer server.Close()
+
+			p := NewOpenAIProvider(DeepSeekPreset, server.URL+"/v1/", tt.apiKey, "")
+			text, err := p.Complete(context.Background(), "hello")
+			if err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+			if text != "ok" {
+				t.Errorf("Complete = %q, want ok", text)
+			}
+			if got != tt.want {
+				t.Errorf("Authorization = %q, want %q", got, tt.want)
+			}
+			if p.Name() != "deepseek" || p.Model() != deepSeekModel {
+				t.Errorf("provider = %s %s, want the deepseek preset", p.Name(), p.Model())
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/config"
+)
+
+// ControlVariant is the variant every prompt has: its main template.
+const ControlVariant = "control"
+
+// ConfigureExperiments splits traffic for each prompt in variants between
+// its weighted variants. Every variant other than control needs a
+// <prompt>@<variant>.tmpl template in the store.
+func (s *PromptStore) ConfigureExperiments(variants map[string][]config.PromptVariant) error {
+	for name, arms := range variants {
+		if s.Get(name) == nil {
+			return fmt.Errorf("experiment for unknown prompt %s", name)
+		}
+		for _, arm := range arms {
+			if s.Get(promptKey(name, arm.Name)) == nil {
+				return fmt.Errorf("prompt %s has no template for variant %s, want %s.tmpl", name, arm.Name, promptKey(name, arm.Name))
+			}
+		}
+	}
+
+	s.experiments.mu.Lock()
+	defer s.experiments.mu.Unlock()
+	s.experiments.arms = variants
+	return nil
+}
+
+type subjectKey struct{}
+
+// WithSubject names who the request is for, such as a user ID or API key,
+// so that each subject keeps the same prompt variants across requests.
+// Requests without a subject get control.
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+func subjectFrom(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	return subject
+}
+
+// experimenting reports whether the named prompt is split between variants.
+func (s *PromptStore) experimenting(name string) bool {
+	s.experiments.mu.RLock()
+	defer s.experiments.mu.RUnlock()
+	return len(s.experiments.arms[name]) > 0
+}
+
+// assignVariant picks the variant of the named prompt for the request's
+// subject. The pick hashes the prompt and subject together, so it is the
+// same on every request and replica but independent between prompts.
+func (s *PromptStore) assignVariant(ctx context.Context, name string) string {
+	s.experiments.mu.RLock()
+	arms := s.experiments.arms[name]
+	s.experiments.mu.RUnlock()
+
+	subject := subjectFrom(ctx)
+	if len(arms) == 0 || subject == "" {
+		return ControlVariant
+	}
+
+	total := 0
+	for _, arm := range arms {
+		total += arm.Weight
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(name + "\x00" + subject))
+	point := int(hash.Sum64() % uint64(total))
+	for _, arm := range arms {
+		if point < arm.Weight {
+			return arm.Name
+		}
+		point -= arm.Weight
+	}
+	return ControlVariant
+}
+
+// VariantStats summarises the outcomes of one prompt version in one variant.
+type VariantStats struct {
+	Prompt  string `json:"prompt"`
+	Variant string `json:"variant"`
+	Version string `json:"version"`
+
+	Calls     int `json:"calls"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	// FirstTry counts successes that needed no repair prompt.
+	FirstTry int `json:"firstTry"`
+	Repairs  int `json:"repairs"`
+
+	SuccessRate  float64 `json:"successRate"`
+	AvgRepairs   float64 `json:"avgRepairs"`
+	AvgLatencyMs int64   `json:"avgLatencyMs"`
+	MaxLatencyMs int64   `json:"maxLatencyMs"`
+
+	totalLatency time.Duration
+	maxLatency   time.Duration
+}
+
+// observe records the outcome of a call made with prompt, as returned by
+// render, that started at started and took repairs repair prompts. Calls
+// the client cancelled say nothing about the prompt and are left out.
+func (s *PromptStore) observe(prompt *Prompt, started time.Time, repairs int, err error) {
+	if prompt == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	latency := time.Since(started)
+
+	s.variantStats.mu.Lock()
+	defer s.variantStats.mu.Unlock()
+	if s.variantStats.stats == nil {
+		s.variantStats.stats = make(map[string]*VariantStats)
+	}
+	// versions are kept apart so an edit mid-experiment does not blur the numbers
+	key := promptKey(prompt.Name, prompt.Variant) + "#" + prompt.Version + "#" + prompt.Hash
+	stats := s.variantStats.stats[key]
+	if stats == nil {
+		stats = &VariantStats{Prompt: prompt.Name, Variant: prompt.Variant, Version: prompt.Version}
+		s.variantStats.stats[key] = stats
+	}
+
+	stats.Calls++
+	stats.Repairs += repairs
+	if err != nil {
+		stats.Failed++
+	} else {
+		stats.Succeeded++
+		if repairs == 0 {
+			stats.FirstTry++
+		}
+	}
+	stats.totalLatency += latency
+	stats.maxLatency = max(stats.maxLatency, latency)
+}
+
+// ExperimentStats lists the outcomes recorded for each prompt variant,
+// sorted by prompt, variant and version.
+func (s *PromptStore) ExperimentStats() []VariantStats {
+	s.variantStats.mu.Lock()
+	defer s.variantStats.mu.Unlock()
+	list := make([]VariantStats, 0, len(s.variantStats.stats))
+	for _, stats := range s.variantStats.stats {
+		summary := *stats
+		summary.SuccessRate = float64(stats.Succeeded) / float64(stats.Calls)
+		summary.AvgRepairs = float64(stats.Repairs) / float64(stats.Calls)
+		summary.AvgLatencyMs = (stats.totalLatency / time.Duration(stats.Calls)).Milliseconds()
+		summary.MaxLatencyMs = stats.maxLatency.Milliseconds()
+		list = append(list, summary)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Prompt != b.Prompt {
+			return a.Prompt < b.Prompt
+		}
+		if a.Variant != b.Variant {
+			return a.Variant < b.Variant
+		}
+		return a.Version < b.Version
+	})
+	return list
+}
+
+// Experiments describes the configured experiments, keyed by prompt name.
+func (s *PromptStore) Experiments() map[string][]config.PromptVariant {
+	s.experiments.mu.RLock()
+	defer s.experiments.mu.RUnlock()
+	return s.experiments.arms
+}
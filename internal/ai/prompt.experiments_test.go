@@ -0,0 +1,50 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestObserveCreditsThePromptSent(t *testing.T) {
+	dir := t.TempDir()
+	override := filepath.Join(dir, "roast.tmpl")
+	if err := os.WriteFile(override, []byte("{{/* version: roast-a */ -}}\nRoast {{.CV}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewPromptStore(dir)
+	if err != nil {
+		t.Fatalf("NewPromptStore: %v", err)
+	}
+
+	_, sent, err := store.render(context.Background(), promptRoast, struct{ CV string }{"cv"})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	// a reload lands while the call is in flight
+	if err := os.WriteFile(override, []byte("{{/* version: roast-b */ -}}\nRoast harder {{.CV}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if got := store.Get(promptRoast).Version; got != "roast-b" {
+		t.Fatalf("reloaded version = %q, want roast-b", got)
+	}
+
+	store.observe(sent, time.Now(), 0, nil)
+	store.observe(sent, time.Now(), 0, context.Canceled)
+	store.observe(nil, time.Now(), 0, errors.New("prompt not found"))
+
+	stats := store.ExperimentStats()
+	if len(stats) != 1 {
+		t.Fatalf("stats = %+v, want one entry", stats)
+	}
+	if got := stats[0]; got.Version != "roast-a" || got.Variant != ControlVariant || got.Calls != 1 || got.Succeeded != 1 {
+		t.Errorf("stats = %+v, want one successful roast-a control call", got)
+	}
+}
@@ -0,0 +1,318 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/config"
+)
+
+//go:embed prompts/*.tmpl
+var embeddedPrompts embed.FS
+
+// Prompt names, matching the template files in prompts/.
+const (
+	promptResume       = "resume"
+	promptResumeRepair = "resume.repair"
+	promptRoast        = "roast"
+	promptCoverLetter  = "cover.letter"
+)
+
+// modePrompts lists the prompts each mode can send.
+var modePrompts = map[string][]string{
+	"format": {promptResume, promptResumeRepair},
+	"roast":  {promptRoast},
+	"letter": {promptCoverLetter},
+}
+
+// promptVersionPattern reads the version a template declares in its leading
+// comment, e.g. {{/* version: resume-4 */ -}}.
+var promptVersionPattern = regexp.MustCompile(`^\{\{-?\s*/\*\s*version:\s*(\S+)\s*\*/`)
+
+// Prompt is one parsed prompt template.
+type Prompt struct {
+	Name string `json:"name"`
+	// Variant is "control" for the prompt's main template, or the experiment
+	// arm a <name>@<variant>.tmpl file provides.
+	Variant string `json:"variant"`
+	// Version is the version the template declares, or name@hash when it
+	// declares none.
+	Version string `json:"version"`
+	// Hash identifies the exact wording, so results cached for one wording
+	// are not reused for an edit that kept the version.
+	Hash string `json:"hash"`
+	// Source is "embedded" or the override file's path.
+	Source string `json:"source"`
+
+	tmpl *template.Template
+}
+
+// parsePrompt parses the template stored under key, a prompt name or
+// name@variant.
+func parsePrompt(key, source string, text []byte) (*Prompt, error) {
+	tmpl, err := template.New(key).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("parsing prompt %s from %s: %w", key, source, err)
+	}
+	sum := sha256.Sum256(text)
+	name, variant := splitPromptKey(key)
+	prompt := &Prompt{
+		Name:    name,
+		Variant: variant,
+		Hash:    hex.EncodeToString(sum[:4]),
+		Source:  source,
+		tmpl:    tmpl,
+	}
+	prompt.Version = key + "@" + prompt.Hash
+	if match := promptVersionPattern.FindSubmatch(text); match != nil {
+		prompt.Version = string(match[1])
+	}
+	return prompt, nil
+}
+
+func (p *Prompt) render(data any) (string, error) {
+	var out strings.Builder
+	if err := p.tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("rendering prompt %s %s: %w", p.Name, p.Version, err)
+	}
+	return out.String(), nil
+}
+
+// promptKey is where the store keeps a prompt's variant.
+func promptKey(name, variant string) string {
+	if variant == "" || variant == ControlVariant {
+		return name
+	}
+	return name + "@" + variant
+}
+
+func splitPromptKey(key string) (name, variant string) {
+	name, variant, ok := strings.Cut(key, "@")
+	if !ok {
+		variant = ControlVariant
+	}
+	return name, variant
+}
+
+// PromptStore holds the embedded prompts, with any overrides from a
+// directory of <name>.tmpl files in their place. Files named
+// <name>@<variant>.tmpl add experiment variants of a known prompt.
+type PromptStore struct {
+	dir string
+
+	mu        sync.RWMutex
+	prompts   map[string]*Prompt
+	signature string // override files as last loaded, to spot changes
+
+	// experiments holds the configured prompt experiments, keyed by prompt
+	// name
+	experiments struct {
+		mu   sync.RWMutex
+		arms map[string][]config.PromptVariant
+	}
+	// variantStats collects outcomes in memory since the store was created
+	variantStats struct {
+		mu    sync.Mutex
+		stats map[string]*VariantStats
+	}
+}
+
+// NewPromptStore loads the embedded prompts, with the overrides in dir in
+// their place when dir is set.
+func NewPromptStore(dir string) (*PromptStore, error) {
+	s := &PromptStore{dir: dir}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload parses the embedded prompts and the overrides. Nothing changes
+// unless every template parses, so a typo in an edited file leaves the
+// previous prompts in use.
+func (s *PromptStore) Reload() error {
+	loaded := make(map[string]*Prompt)
+	entries, err := embeddedPrompts.ReadDir("prompts")
+	if err != nil {
+		return fmt.Errorf("reading embedded prompts: %w", err)
+	}
+	for _, entry := range entries {
+		key := strings.TrimSuffix(entry.Name(), ".tmpl")
+		text, err := embeddedPrompts.ReadFile("prompts/" + entry.Name())
+		if err != nil {
+			return fmt.Errorf("reading embedded prompt %s: %w", key, err)
+		}
+		if loaded[key], err = parsePrompt(key, "embedded", text); err != nil {
+			return err
+		}
+	}
+
+	s.mu.RLock()
+	dir := s.dir
+	s.mu.RUnlock()
+
+	var signature string
+	if dir != "" {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+		if err != nil {
+			return fmt.Errorf("listing prompt overrides: %w", err)
+		}
+		signature = overrideSignature(paths)
+		for _, path := range paths {
+			key := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+			// a variant may be new, but only of a prompt the service sends
+			if name, _ := splitPromptKey(key); loaded[name] == nil {
+				log.Printf("Ignoring unknown prompt override %s", path)
+				continue
+			}
+			text, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("reading prompt override %s: %w", path, err)
+			}
+			if loaded[key], err = parsePrompt(key, path, text); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, prompt := range loaded {
+		if previous := s.prompts[key]; previous == nil || previous.Hash != prompt.Hash {
+			log.Printf("Loaded prompt %s version %s (%s, %s)", key, prompt.Version, prompt.Hash, prompt.Source)
+		}
+	}
+	s.prompts = loaded
+	s.signature = signature
+	return nil
+}
+
+// Watch polls the override directory and reloads when its files change,
+// checking every interval until ctx is done.
+func (s *PromptStore) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.RLock()
+		dir, signature := s.dir, s.signature
+		s.mu.RUnlock()
+		if dir == "" {
+			continue
+		}
+		paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+		if err != nil {
+			continue
+		}
+		current := overrideSignature(paths)
+		if current == signature {
+			continue
+		}
+		if err := s.Reload(); err != nil {
+			log.Printf("Keeping previous prompts, reload failed: %v", err)
+			// wait for the next edit rather than failing every tick
+			s.mu.Lock()
+			s.signature = current
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Get returns the prompt stored under key, a name or name@variant.
+func (s *PromptStore) Get(key string) *Prompt {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.prompts[key]
+}
+
+// List describes the prompts in use, sorted by name.
+func (s *PromptStore) List() []Prompt {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]Prompt, 0, len(s.prompts))
+	for _, prompt := range s.prompts {
+		list = append(list, *prompt)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		// control first, then the variants
+		return promptKey(list[i].Name, list[i].Variant) < promptKey(list[j].Name, list[j].Variant)
+	})
+	return list
+}
+
+// overrideSignature summarises the files' names, sizes and modification
+// times; any edit, addition or removal changes it.
+func overrideSignature(paths []string) string {
+	var signature strings.Builder
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&signature, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+	}
+	return signature.String()
+}
+
+// selectPrompt returns the variant of the named prompt assigned to the
+// request's subject, or control when that variant's template is missing.
+func (s *PromptStore) selectPrompt(ctx context.Context, name string) *Prompt {
+	variant := s.assignVariant(ctx, name)
+	if prompt := s.Get(promptKey(name, variant)); prompt != nil {
+		return prompt
+	}
+	if variant != ControlVariant {
+		log.Printf("Prompt %s has no variant %s, using control", name, variant)
+	}
+	return s.Get(name)
+}
+
+// render renders the named prompt, in the variant assigned to the
+// request, with data and records its version in the request trace. It
+// returns the prompt it used, so the call's outcome is credited to that
+// prompt even if a reload replaces it meanwhile.
+func (s *PromptStore) render(ctx context.Context, name string, data any) (string, *Prompt, error) {
+	prompt := s.selectPrompt(ctx, name)
+	if prompt == nil {
+		return "", nil, fmt.Errorf("prompt %s not found", name)
+	}
+	text, err := prompt.render(data)
+	if err != nil {
+		return "", nil, err
+	}
+	log.Printf("Using prompt %s version %s", promptKey(name, prompt.Variant), prompt.Version)
+	recordPrompt(ctx, prompt, s.experimenting(prompt.Name))
+	return text, prompt, nil
+}
+
+// PromptVersion identifies the wording of every prompt mode would send for
+// the request, variants included, for use in cache keys.
+func (s *PromptStore) PromptVersion(ctx context.Context, mode string) string {
+	var parts []string
+	for _, name := range modePrompts[mode] {
+		if prompt := s.selectPrompt(ctx, name); prompt != nil {
+			parts = append(parts, prompt.Version+"#"+prompt.Hash)
+		}
+	}
+	return strings.Join(parts, ",")
+}
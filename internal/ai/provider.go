@@ -0,0 +1,81 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/config"
+)
+
+const defaultTemperature = 0.7
+
+// LLMProvider is a chat model backend that turns a single prompt into a completion.
+type LLMProvider interface {
+	// Name identifies the provider in logs, e.g. "deepseek" or "ollama".
+	Name() string
+	// Model is the model the provider sends requests to.
+	Model() string
+	// TokenLimits is the model's context window and completion limit.
+	TokenLimits() TokenLimits
+	Complete(ctx context.Context, prompt string) (string, error)
+	// Stream is Complete, but also passes each piece of the completion to
+	// onDelta as the model produces it.
+	Stream(ctx context.Context, prompt string, onDelta func(string)) (string, error)
+}
+
+// CircuitReporter is implemented by providers guarded by a circuit breaker.
+type CircuitReporter interface {
+	Circuit() CircuitSnapshot
+}
+
+// complete runs prompt on provider, streaming to onDelta when it is set.
+func complete(ctx context.Context, provider LLMProvider, prompt string, onDelta func(string)) (string, error) {
+	if onDelta == nil {
+		return provider.Complete(ctx, prompt)
+	}
+	return provider.Stream(ctx, prompt, onDelta)
+}
+
+// NewProvider builds the LLMProvider for one entry of a mode's fallback chain.
+func NewProvider(cfg *config.Config, route config.ModelRoute) (LLMProvider, error) {
+	temperature := defaultTemperature
+	if route.Temperature != nil {
+		temperature = *route.Temperature
+	}
+
+	switch strings.ToLower(route.Provider) {
+	case "", config.ProviderDeepSeek:
+		p := NewOpenAIProvider(DeepSeekPreset, cfg.BaseURL(config.ProviderDeepSeek), cfg.APIKey(config.ProviderDeepSeek), route.Model)
+		p.temperature = temperature
+		p.limits = configuredLimits(cfg, p.model, p.limits)
+		return p, nil
+	case config.ProviderOpenAI:
+		p := NewOpenAIProvider(OpenAIPreset, cfg.BaseURL(config.ProviderOpenAI), cfg.APIKey(config.ProviderOpenAI), route.Model)
+		p.temperature = temperature
+		p.limits = configuredLimits(cfg, p.model, p.limits)
+		return p, nil
+	case config.ProviderOllama:
+		p := NewOllamaProvider(cfg.BaseURL(config.ProviderOllama), route.Model)
+		p.temperature = temperature
+		p.limits = configuredLimits(cfg, p.model, p.limits)
+		return p, nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider: %s", route.Provider)
+	}
+}
+
+// configuredLimits applies a MODEL_TOKEN_LIMITS entry for model to defaults.
+// Without an output limit of its own the model keeps its default one, unless
+// that no longer fits the window.
+func configuredLimits(cfg *config.Config, model string, defaults TokenLimits) TokenLimits {
+	override, ok := cfg.ModelTokenLimits[model]
+	if !ok {
+		return defaults
+	}
+	limits := TokenLimits{Context: override.Context, Output: override.Output}
+	if limits.Output == 0 {
+		limits.Output = min(defaults.Output, limits.Context)
+	}
+	return limits
+}
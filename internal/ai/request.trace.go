@@ -0,0 +1,91 @@
+package ai
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+// Trace records what served the LLM calls made with a context from
+// WithTrace: the provider and model that answered last, the version of
+// each prompt sent and the variant of each prompt under experiment.
+type Trace struct {
+	mu       sync.Mutex
+	provider string
+	model    string
+	fallback bool
+	prompts  map[string]string
+	variants map[string]string
+}
+
+type traceKey struct{}
+
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	trace := &Trace{}
+	return context.WithValue(ctx, traceKey{}, trace), trace
+}
+
+// Served reports the provider and model, and whether it was a fallback rather
+// than the first in its chain. Both names are empty if no call succeeded.
+func (t *Trace) Served() (provider, model string, fallback bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.provider, t.model, t.fallback
+}
+
+// Prompts maps each prompt sent to its version, or is nil if none was.
+func (t *Trace) Prompts() map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.prompts) == 0 {
+		return nil
+	}
+	return maps.Clone(t.prompts)
+}
+
+// Variants maps each prompt sent under an experiment to the variant used,
+// or is nil if there were none.
+func (t *Trace) Variants() map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.variants) == 0 {
+		return nil
+	}
+	return maps.Clone(t.variants)
+}
+
+func traceFrom(ctx context.Context) *Trace {
+	trace, _ := ctx.Value(traceKey{}).(*Trace)
+	return trace
+}
+
+func recordServed(ctx context.Context, provider LLMProvider, fallback bool) {
+	trace := traceFrom(ctx)
+	if trace == nil {
+		return
+	}
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	trace.provider = provider.Name()
+	trace.model = provider.Model()
+	trace.fallback = fallback
+}
+
+func recordPrompt(ctx context.Context, prompt *Prompt, experimenting bool) {
+	trace := traceFrom(ctx)
+	if trace == nil {
+		return
+	}
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	if trace.prompts == nil {
+		trace.prompts = make(map[string]string)
+	}
+	trace.prompts[prompt.Name] = prompt.Version
+	if experimenting {
+		if trace.variants == nil {
+			trace.variants = make(map[string]string)
+		}
+		trace.variants[prompt.Name] = prompt.Variant
+	}
+}
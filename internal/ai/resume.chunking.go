@@ -0,0 +1,352 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/dtos"
+)
+
+const (
+	// the resume JSON for a piece of CV runs to about 1.5 times its tokens,
+	// plus a fixed amount of structure
+	resumeOutputOverhead = 300
+	// below this a part holds too little of the CV to parse sensibly
+	minResumeChunkTokens = 500
+	// parts parsed at the same time, to stay clear of rate limits
+	maxConcurrentChunks = 3
+)
+
+// maxResumeChunkTokens is the most CV text that fits one call when the rest
+// of the prompt costs fixedTokens: prompt and output must share the window,
+// and the output must fit the completion limit.
+func maxResumeChunkTokens(limits TokenLimits, fixedTokens int) int {
+	byContext := (limits.Context - fixedTokens - resumeOutputOverhead) * 2 / 5
+	byOutput := (limits.Output - resumeOutputOverhead) * 2 / 3
+	return min(byContext, byOutput)
+}
+
+// sectionHeadingWords end the headings CVs use for their sections, e.g.
+// "Work Experience", "Selected Publications" or "HONORS".
+var sectionHeadingWords = map[string]bool{
+	"experience": true, "experiences": true, "history": true, "employment": true,
+	"education": true, "qualifications": true, "background": true,
+	"skills": true, "competencies": true, "expertise": true, "proficiencies": true,
+	"projects": true, "portfolio": true,
+	"awards": true, "honors": true, "honours": true, "achievements": true, "recognition": true,
+	"certifications": true, "certificates": true, "licenses": true,
+	"summary": true, "profile": true, "objective": true, "about": true, "me": true,
+	"publications": true, "presentations": true, "talks": true, "conferences": true,
+	"grants": true, "funding": true, "research": true, "teaching": true,
+	"service": true, "activities": true, "volunteering": true, "leadership": true,
+	"languages": true, "interests": true, "references": true, "memberships": true,
+	"affiliations": true, "patents": true, "courses": true, "training": true,
+}
+
+// isSectionHeading reports whether line looks like a section heading: a
+// short title-case or upper-case line ending in a known heading word.
+func isSectionHeading(line string) bool {
+	line = strings.TrimSuffix(strings.TrimSpace(line), ":")
+	words := strings.Fields(line)
+	if len(words) == 0 || len(words) > 5 || len(line) > 50 {
+		return false
+	}
+	if !sectionHeadingWords[strings.ToLower(strings.Trim(words[len(words)-1], "&,"))] {
+		return false
+	}
+	for _, word := range words {
+		first := []rune(word)[0]
+		if unicode.IsLetter(first) && !unicode.IsUpper(first) && len(word) > 3 {
+			return false
+		}
+	}
+	return true
+}
+
+type cvSection struct {
+	heading string
+	text    string
+}
+
+// splitCVSections cuts text at section headings; anything before the first
+// heading, usually the contact details, is a section without a heading.
+func splitCVSections(text string) []cvSection {
+	var sections []cvSection
+	current := cvSection{}
+	var lines []string
+	flush := func() {
+		current.text = strings.TrimSpace(strings.Join(lines, "\n"))
+		if current.text != "" {
+			sections = append(sections, current)
+		}
+		lines = nil
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if isSectionHeading(line) {
+			flush()
+			current = cvSection{heading: strings.TrimSpace(line)}
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return sections
+}
+
+// splitCV divides text into parts of at most maxTokens, cutting between
+// sections where possible. A section too big for one part is cut between
+// paragraphs, then lines, then words, then by length for a word longer than
+// a part, and each following piece is headed
+// "<heading> (continued)" so the model knows which section it belongs to.
+func splitCV(text string, maxTokens int) []string {
+	var pieces []string
+	for _, section := range splitCVSections(text) {
+		if EstimateTokens(section.text) <= maxTokens {
+			pieces = append(pieces, section.text)
+			continue
+		}
+		continued := ""
+		if section.heading != "" {
+			continued = section.heading + " (continued)\n"
+		}
+		// the estimate of joined text can round one token above the sum of
+		// its parts
+		budget := maxTokens - EstimateTokens(continued) - 1
+		for i, piece := range splitText(section.text, budget, []string{"\n\n", "\n", " "}) {
+			if i > 0 {
+				piece = continued + piece
+			}
+			pieces = append(pieces, piece)
+		}
+	}
+	return packPieces(pieces, maxTokens, "\n\n")
+}
+
+// splitText cuts text at the first separator that yields pieces within
+// maxTokens, falling back to the next separator for pieces still too big.
+// Text without separators left, such as a pasted base64 blob or a long run
+// of URLs, is cut by length.
+func splitText(text string, maxTokens int, separators []string) []string {
+	if EstimateTokens(text) <= maxTokens {
+		return []string{text}
+	}
+	if len(separators) == 0 {
+		return splitRunes(text, maxTokens)
+	}
+	var pieces []string
+	for _, part := range strings.Split(text, separators[0]) {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		pieces = append(pieces, splitText(part, maxTokens, separators[1:])...)
+	}
+	return packPieces(pieces, maxTokens, separators[0])
+}
+
+// splitRunes cuts text into the longest runs of whole runes within
+// maxTokens, always at least one rune each.
+func splitRunes(text string, maxTokens int) []string {
+	var pieces []string
+	runes := []rune(text)
+	for len(runes) > 0 {
+		// a rune is at least a quarter of a token, which bounds the search
+		limit := min(len(runes), 4*max(maxTokens, 1)+4)
+		n := sort.Search(limit, func(n int) bool {
+			return EstimateTokens(string(runes[:n+1])) > maxTokens
+		})
+		n = max(n, 1)
+		pieces = append(pieces, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return pieces
+}
+
+// packPieces joins consecutive pieces with sep while they fit maxTokens.
+func packPieces(pieces []string, maxTokens int, sep string) []string {
+	var packed []string
+	var current string
+	for _, piece := range pieces {
+		if current == "" {
+			current = piece
+			continue
+		}
+		if joined := current + sep + piece; EstimateTokens(joined) <= maxTokens {
+			current = joined
+			continue
+		}
+		packed = append(packed, current)
+		current = piece
+	}
+	if current != "" {
+		packed = append(packed, current)
+	}
+	return packed
+}
+
+// parseResumeChunks parses the parts of a long CV concurrently and merges
+// them in order. The first failure cancels the remaining parts.
+func parseResumeChunks(ctx context.Context, chunks []string, jobDescription string, provider LLMProvider, prompts *PromptStore) (*dtos.Resume, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	parts := make([]*dtos.Resume, len(chunks))
+	slots := make(chan struct{}, maxConcurrentChunks)
+	var wg sync.WaitGroup
+	var failOnce sync.Once
+	var failure error
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			if ctx.Err() != nil {
+				return
+			}
+
+			prompt, sent, err := resumePrompt(ctx, prompts, jobDescription, chunk, i+1, len(chunks))
+			var part *dtos.Resume
+			if err == nil {
+				part, err = parseResume(ctx, prompt, sent, provider, prompts)
+			}
+			if err != nil {
+				// only the first failure is reported, not the cancellations it causes
+				failOnce.Do(func() {
+					failure = fmt.Errorf("parsing part %d of %d: %w", i+1, len(chunks), err)
+				})
+				cancel()
+				return
+			}
+			parts[i] = part
+		}()
+	}
+	wg.Wait()
+
+	if failure != nil {
+		return nil, failure
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	resume := mergeResumes(parts)
+	ValidateAndFillMissingSections(resume)
+	return resume, nil
+}
+
+// mergeResumes combines the resumes parsed from the parts of one CV. Header
+// fields and the summary come from the first part that has them; entries
+// that were split across parts are joined.
+func mergeResumes(parts []*dtos.Resume) *dtos.Resume {
+	merged := &dtos.Resume{}
+	skills := make(map[string]int)
+	experiences := make(map[string]int)
+	education := make(map[string]int)
+	projects := make(map[string]int)
+	awards := make(map[string]int)
+
+	for _, part := range parts {
+		mergeHeader(&merged.Header, part.Header)
+		if merged.ProfileSummary == "" {
+			merged.ProfileSummary = part.ProfileSummary
+		}
+		if len(merged.SectionOrder) == 0 {
+			merged.SectionOrder = part.SectionOrder
+		}
+
+		for _, skill := range part.Skills {
+			key := mergeKey(skill.Title)
+			if i, ok := skills[key]; ok {
+				merged.Skills[i].Values = appendUnique(merged.Skills[i].Values, skill.Values...)
+				continue
+			}
+			skills[key] = len(merged.Skills)
+			merged.Skills = append(merged.Skills, skill)
+		}
+		for _, experience := range part.Experiences {
+			key := mergeKey(experience.Company, experience.Occupation, experience.StartDate)
+			if i, ok := experiences[key]; ok {
+				merged.Experiences[i].Descriptions = appendUnique(merged.Experiences[i].Descriptions, experience.Descriptions...)
+				continue
+			}
+			experiences[key] = len(merged.Experiences)
+			merged.Experiences = append(merged.Experiences, experience)
+		}
+		for _, entry := range part.Education {
+			key := mergeKey(entry.Institution, entry.Degree, entry.StartDate)
+			if i, ok := education[key]; ok {
+				merged.Education[i].Descriptions = appendUnique(merged.Education[i].Descriptions, entry.Descriptions...)
+				continue
+			}
+			education[key] = len(merged.Education)
+			merged.Education = append(merged.Education, entry)
+		}
+		for _, project := range part.Projects {
+			key := mergeKey(project.Title)
+			if i, ok := projects[key]; ok {
+				merged.Projects[i].Descriptions = appendUnique(merged.Projects[i].Descriptions, project.Descriptions...)
+				continue
+			}
+			projects[key] = len(merged.Projects)
+			merged.Projects = append(merged.Projects, project)
+		}
+		for _, award := range part.Awards {
+			key := mergeKey(award.Title, award.Issuer, award.Date)
+			if i, ok := awards[key]; ok {
+				merged.Awards[i].Descriptions = appendUnique(merged.Awards[i].Descriptions, award.Descriptions...)
+				continue
+			}
+			awards[key] = len(merged.Awards)
+			merged.Awards = append(merged.Awards, award)
+		}
+	}
+	return merged
+}
+
+func mergeHeader(into *dtos.Header, from dtos.Header) {
+	fields := []struct{ into, from *string }{
+		{&into.Fullname, &from.Fullname},
+		{&into.JobTitle, &from.JobTitle},
+		{&into.Location, &from.Location},
+		{&into.Email, &from.Email},
+		{&into.Phone, &from.Phone},
+		{&into.LinkedIn, &from.LinkedIn},
+		{&into.LinkedInURL, &from.LinkedInURL},
+		{&into.Github, &from.Github},
+		{&into.GithubURL, &from.GithubURL},
+		{&into.Website, &from.Website},
+		{&into.WebsiteURL, &from.WebsiteURL},
+	}
+	for _, field := range fields {
+		if *field.into == "" {
+			*field.into = *field.from
+		}
+	}
+}
+
+func mergeKey(values ...string) string {
+	for i, value := range values {
+		values[i] = strings.ToLower(strings.TrimSpace(value))
+	}
+	return strings.Join(values, "\x00")
+}
+
+// appendUnique appends the values not already in list, ignoring case.
+func appendUnique(list []string, values ...string) []string {
+	seen := make(map[string]bool, len(list))
+	for _, value := range list {
+		seen[strings.ToLower(strings.TrimSpace(value))] = true
+	}
+	for _, value := range values {
+		key := strings.ToLower(strings.TrimSpace(value))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		list = append(list, value)
+	}
+	return list
+}
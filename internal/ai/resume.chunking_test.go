@@ -0,0 +1,144 @@
+package ai
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/dtos"
+)
+
+func TestPackPieces(t *testing.T) {
+	tests := []struct {
+		name      string
+		pieces    []string
+		maxTokens int
+		want      []string
+	}{
+		{"none", nil, 10, nil},
+		{"all fit", []string{"aaaa", "bbbb", "cccc"}, 10, []string{"aaaa bbbb cccc"}},
+		{"packed in order", []string{"aaaa", "bbbb", "cccc"}, 3, []string{"aaaa bbbb", "cccc"}},
+		{"too big alone", []string{strings.Repeat("a", 40), "bbbb"}, 3, []string{strings.Repeat("a", 40), "bbbb"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := packPieces(tt.pieces, tt.maxTokens, " "); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("packPieces = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitCV(t *testing.T) {
+	bullet := "- Built payment services in Go and PostgreSQL for merchants across Lagos"
+	experience := "Work Experience\n" + strings.Repeat(bullet+"\n", 12)
+	cv := "Ada Okafor\nLagos\n\nSkills\nGo, PostgreSQL\n\n" + experience + "\nEducation\nBSc Computer Science, University of Lagos"
+
+	tests := []struct {
+		name      string
+		text      string
+		parts     int
+		continued int
+	}{
+		{"fits", "Ada Okafor\nLagos\n\nSkills\nGo, PostgreSQL", 1, 0},
+		{"long section by lines", cv, 4, 2},
+		{"word longer than a part", "Portfolio\n" + strings.Repeat("QUJDREVGR0hJSktMTU5PUFFSU1RVVldYWVo", 40), 6, 5},
+		{"wide runes without spaces", "Languages\n" + strings.Repeat("数据工程", 100), 6, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := splitCV(tt.text, 100)
+			if len(parts) != tt.parts {
+				t.Fatalf("splitCV made %d parts, want %d", len(parts), tt.parts)
+			}
+			var text []string
+			continued := 0
+			for i, part := range parts {
+				if tokens := EstimateTokens(part); tokens > 100 {
+					t.Errorf("part %d is %d tokens, over 100", i, tokens)
+				}
+				if heading, rest, ok := strings.Cut(part, " (continued)\n"); ok && !strings.Contains(heading, "\n") {
+					continued++
+					part = rest
+				}
+				text = append(text, part)
+			}
+			if continued != tt.continued {
+				t.Errorf("%d parts continue a section, want %d", continued, tt.continued)
+			}
+			if squeeze(strings.Join(text, "")) != squeeze(tt.text) {
+				t.Errorf("parts do not hold the whole CV:\n%q", parts)
+			}
+		})
+	}
+}
+
+// squeeze drops whitespace, to compare text regardless of where it was cut.
+func squeeze(text string) string {
+	return strings.Join(strings.Fields(text), "")
+}
+
+func TestSplitRunes(t *testing.T) {
+	text := strings.Repeat("é", 25)
+	pieces := splitRunes(text, 11)
+	if got := strings.Join(pieces, ""); got != text {
+		t.Fatalf("pieces join to %q, want %q", got, text)
+	}
+	for i, piece := range pieces {
+		if tokens := EstimateTokens(piece); tokens > 11 {
+			t.Errorf("piece %d is %d tokens, over 11", i, tokens)
+		}
+	}
+	if len(pieces) != 3 {
+		t.Errorf("splitRunes made %d pieces, want 3 of 10, 10 and 5 runes", len(pieces))
+	}
+	if got := splitRunes("abc", 0); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("splitRunes with no budget = %q, want a rune each", got)
+	}
+}
+
+func TestMergeResumes(t *testing.T) {
+	first := &dtos.Resume{
+		Header:         dtos.Header{Fullname: "Ada Okafor", Email: "ada.okafor@example.com"},
+		ProfileSummary: "Backend engineer.",
+		SectionOrder:   []string{"experiences", "skills"},
+		Skills:         []dtos.Skills{{Title: "Technical Skills", Values: []string{"Go", "PostgreSQL"}}},
+		Experiences: []dtos.Experience{{
+			Company: "Paystack", Occupation: "Senior Backend Engineer", StartDate: "Mar 2021",
+			Descriptions: []string{"Cut settlement latency by 35%"},
+		}},
+	}
+	second := &dtos.Resume{
+		Header:         dtos.Header{Fullname: "A. Okafor", Phone: "+234 801 234 5678"},
+		ProfileSummary: "Ignored.",
+		SectionOrder:   []string{"education"},
+		Skills:         []dtos.Skills{{Title: "technical skills ", Values: []string{"go", "Kafka"}}},
+		Experiences: []dtos.Experience{
+			{
+				Company: "paystack", Occupation: "Senior Backend Engineer", StartDate: "Mar 2021",
+				Descriptions: []string{"cut settlement latency by 35%", "Led a team of 4 engineers"},
+			},
+			{Company: "Interswitch", Occupation: "Backend Engineer", StartDate: "Jan 2019"},
+		},
+		Education: []dtos.Education{{Institution: "University of Lagos", Degree: "BSc Computer Science"}},
+	}
+
+	got := mergeResumes([]*dtos.Resume{first, second})
+	want := &dtos.Resume{
+		Header:         dtos.Header{Fullname: "Ada Okafor", Email: "ada.okafor@example.com", Phone: "+234 801 234 5678"},
+		ProfileSummary: "Backend engineer.",
+		SectionOrder:   []string{"experiences", "skills"},
+		Skills:         []dtos.Skills{{Title: "Technical Skills", Values: []string{"Go", "PostgreSQL", "Kafka"}}},
+		Experiences: []dtos.Experience{
+			{
+				Company: "Paystack", Occupation: "Senior Backend Engineer", StartDate: "Mar 2021",
+				Descriptions: []string{"Cut settlement latency by 35%", "Led a team of 4 engineers"},
+			},
+			{Company: "Interswitch", Occupation: "Backend Engineer", StartDate: "Jan 2019"},
+		},
+		Education: []dtos.Education{{Institution: "University of Lagos", Degree: "BSc Computer Science"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeResumes =\n%+v\nwant\n%+v", got, want)
+	}
+}
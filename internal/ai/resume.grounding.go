@@ -0,0 +1,357 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/dtos"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// FabricationPolicy decides what happens to resume items that cannot be
+// found in the source CV.
+type FabricationPolicy string
+
+const (
+	// FabricationWarn keeps the items and reports them as warnings.
+	FabricationWarn FabricationPolicy = "warn"
+	// FabricationStrip removes the items and reports what was removed.
+	FabricationStrip FabricationPolicy = "strip"
+	// FabricationReject fails the request.
+	FabricationReject FabricationPolicy = "reject"
+)
+
+func ParseFabricationPolicy(value string) (FabricationPolicy, error) {
+	switch policy := FabricationPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case FabricationWarn, FabricationStrip, FabricationReject:
+		return policy, nil
+	case "":
+		return FabricationWarn, nil
+	default:
+		return "", fmt.Errorf("unknown fabrication policy %q (use warn, strip or reject)", value)
+	}
+}
+
+// Warning is a resume item the model returned that does not appear in the
+// source CV. Path is a JSON pointer into the resume as the model returned it.
+type Warning struct {
+	Path    string `json:"path"`
+	Kind    string `json:"kind"`
+	Value   string `json:"value"`
+	Message string `json:"message"`
+	Removed bool   `json:"removed,omitempty"`
+}
+
+// FabricationError is returned under FabricationReject when the resume
+// contains items not found in the source CV.
+type FabricationError struct {
+	Warnings []Warning
+}
+
+func (e *FabricationError) Error() string {
+	return fmt.Sprintf("optimized resume contains %d items not found in the CV, first: %s %q",
+		len(e.Warnings), e.Warnings[0].Kind, e.Warnings[0].Value)
+}
+
+// CheckGrounding compares resume against the text it was generated from and
+// applies policy to companies, institutions, degrees, certifications, dates
+// and metrics that the source does not mention. Matching is deliberately
+// loose (case, accents, punctuation and legal suffixes are ignored) so
+// warnings point at invented content rather than rephrasing.
+func CheckGrounding(resume *dtos.Resume, sourceText string, policy FabricationPolicy) ([]Warning, error) {
+	source := newGroundingSource(sourceText)
+	var warnings []Warning
+	flag := func(path, kind, value, message string) {
+		warnings = append(warnings, Warning{Path: path, Kind: kind, Value: value, Message: message})
+	}
+
+	checkDate := func(path, value string) {
+		if year := dateYear(value); year != "" && !source.numbers[year] {
+			flag(path, "date", value, "year "+year+" does not appear in the CV")
+		}
+	}
+	checkLines := func(path string, lines []string) {
+		for i, line := range lines {
+			for _, metric := range source.missingMetrics(line) {
+				flag(fmt.Sprintf("%s/%d", path, i), "metric", metric, "figure "+metric+" does not appear in the CV")
+			}
+		}
+	}
+
+	for _, metric := range source.missingMetrics(resume.ProfileSummary) {
+		flag("/profileSummary", "metric", metric, "figure "+metric+" does not appear in the CV")
+	}
+	for i, exp := range resume.Experiences {
+		path := fmt.Sprintf("/experiences/%d", i)
+		if !source.hasPhrase(exp.Company, companyStopwords) {
+			flag(path+"/company", "company", exp.Company, "company does not appear in the CV")
+		}
+		checkDate(path+"/startDate", exp.StartDate)
+		checkDate(path+"/endDate", exp.EndDate)
+		checkLines(path+"/desc", exp.Descriptions)
+	}
+	for i, edu := range resume.Education {
+		path := fmt.Sprintf("/education/%d", i)
+		if !source.hasPhrase(edu.Institution, institutionStopwords) {
+			flag(path+"/institution", "institution", edu.Institution, "institution does not appear in the CV")
+		}
+		if !source.hasPhrase(edu.Degree, degreeStopwords) {
+			flag(path+"/degree", "degree", edu.Degree, "degree subject does not appear in the CV")
+		}
+		checkDate(path+"/startDate", edu.StartDate)
+		checkDate(path+"/endDate", edu.EndDate)
+		checkLines(path+"/desc", edu.Descriptions)
+	}
+	for i, project := range resume.Projects {
+		checkLines(fmt.Sprintf("/projects/%d/desc", i), project.Descriptions)
+	}
+	for i, award := range resume.Awards {
+		path := fmt.Sprintf("/awards/%d", i)
+		if !source.hasPhrase(award.Title, companyStopwords) {
+			flag(path+"/title", "award", award.Title, "award or certification does not appear in the CV")
+		}
+		if !source.hasPhrase(award.Issuer, companyStopwords) {
+			flag(path+"/issuer", "issuer", award.Issuer, "issuer does not appear in the CV")
+		}
+		checkDate(path+"/date", award.Date)
+		checkLines(path+"/desc", award.Descriptions)
+	}
+
+	if len(warnings) == 0 {
+		return nil, nil
+	}
+	switch policy {
+	case FabricationReject:
+		return warnings, &FabricationError{Warnings: warnings}
+	case FabricationStrip:
+		stripUngrounded(resume, warnings)
+	}
+	return warnings, nil
+}
+
+// stripUngrounded removes flagged items: whole entries for invented
+// employers, institutions, degrees and awards, the date for invented dates,
+// and the bullet or summary sentence carrying an invented figure.
+func stripUngrounded(resume *dtos.Resume, warnings []Warning) {
+	dropEntry := map[string]bool{}
+	dropLine := map[string]bool{}
+	var summaryMetrics []string
+	for i := range warnings {
+		w := &warnings[i]
+		w.Removed = true
+		entry := w.Path[:strings.LastIndex(w.Path, "/")]
+		switch w.Kind {
+		case "company", "institution", "degree", "award":
+			dropEntry[entry] = true
+		case "issuer":
+			// the award itself may be real; only the issuer is invented
+		case "date":
+			// cleared below
+		case "metric":
+			if w.Path == "/profileSummary" {
+				summaryMetrics = append(summaryMetrics, w.Value)
+			} else {
+				dropLine[w.Path] = true
+			}
+		}
+	}
+
+	clearField := func(path string, value *string) {
+		for _, w := range warnings {
+			if w.Path == path && (w.Kind == "date" || w.Kind == "issuer") {
+				*value = ""
+			}
+		}
+	}
+	lines := func(path string, values []string) []string {
+		kept := []string{}
+		for i, value := range values {
+			if !dropLine[fmt.Sprintf("%s/%d", path, i)] {
+				kept = append(kept, value)
+			}
+		}
+		return kept
+	}
+
+	experiences := []dtos.Experience{}
+	for i, exp := range resume.Experiences {
+		path := fmt.Sprintf("/experiences/%d", i)
+		if dropEntry[path] {
+			continue
+		}
+		clearField(path+"/startDate", &exp.StartDate)
+		clearField(path+"/endDate", &exp.EndDate)
+		exp.Descriptions = lines(path+"/desc", exp.Descriptions)
+		experiences = append(experiences, exp)
+	}
+	resume.Experiences = experiences
+
+	education := []dtos.Education{}
+	for i, edu := range resume.Education {
+		path := fmt.Sprintf("/education/%d", i)
+		if dropEntry[path] {
+			continue
+		}
+		clearField(path+"/startDate", &edu.StartDate)
+		clearField(path+"/endDate", &edu.EndDate)
+		edu.Descriptions = lines(path+"/desc", edu.Descriptions)
+		education = append(education, edu)
+	}
+	resume.Education = education
+
+	for i := range resume.Projects {
+		resume.Projects[i].Descriptions = lines(fmt.Sprintf("/projects/%d/desc", i), resume.Projects[i].Descriptions)
+	}
+
+	awards := []dtos.Award{}
+	for i, award := range resume.Awards {
+		path := fmt.Sprintf("/awards/%d", i)
+		if dropEntry[path] {
+			continue
+		}
+		clearField(path+"/issuer", &award.Issuer)
+		clearField(path+"/date", &award.Date)
+		award.Descriptions = lines(path+"/desc", award.Descriptions)
+		awards = append(awards, award)
+	}
+	resume.Awards = awards
+
+	if len(summaryMetrics) > 0 {
+		var kept []string
+		for _, sentence := range sentences(resume.ProfileSummary) {
+			invented := false
+			for _, metric := range summaryMetrics {
+				invented = invented || strings.Contains(sentence, metric)
+			}
+			if !invented {
+				kept = append(kept, strings.TrimSpace(sentence))
+			}
+		}
+		resume.ProfileSummary = strings.Join(kept, " ")
+	}
+}
+
+var (
+	// metricPattern matches a whitespace-separated figure such as 35%, $1.2M, 10x or 1,200+
+	metricPattern = regexp.MustCompile(`(?i)^[$€£]?((?:\d{1,3}(?:,\d{3})+|\d+)(?:\.\d+)?)(%|x|k|m|bn|\+)?$`)
+	sourceNumber  = regexp.MustCompile(`(?:\d{1,3}(?:,\d{3})+|\d+)(?:\.\d+)?`)
+	yearPattern   = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+)
+
+var (
+	companyStopwords = stopwords("the of and a an at for in inc ltd llc plc limited co corp corporation company gmbh sa nv bv ag pty group holdings")
+	// institutions are often abbreviated, so only the distinctive words must match
+	institutionStopwords = stopwords("the of and a an at for in university univ uni college school institute inst polytechnic academy")
+	// the level of a degree is written many ways (BSc, B.Sc., Bachelor of Science); its subject is what gets invented
+	degreeStopwords = stopwords("the of and a an at for in with degree bachelor bachelors master masters doctor doctorate phd science sciences arts engineering technology bsc ba bs beng btech msc ma ms meng mtech mba hons honours honors first second class upper lower diploma certificate national ond hnd")
+)
+
+func stopwords(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// groundingSource is the normalised source CV.
+type groundingSource struct {
+	text    string
+	words   map[string]bool
+	numbers map[string]bool
+}
+
+func newGroundingSource(text string) *groundingSource {
+	source := &groundingSource{
+		text:    " " + normalizeForMatch(text) + " ",
+		words:   make(map[string]bool),
+		numbers: make(map[string]bool),
+	}
+	for _, word := range strings.Fields(source.text) {
+		source.words[word] = true
+	}
+	for _, number := range sourceNumber.FindAllString(text, -1) {
+		source.numbers[strings.ReplaceAll(number, ",", "")] = true
+	}
+	return source
+}
+
+// hasPhrase reports whether phrase appears in the source, either verbatim
+// or with at least two thirds of its distinctive words present.
+func (s *groundingSource) hasPhrase(phrase string, ignore map[string]bool) bool {
+	normalized := normalizeForMatch(phrase)
+	if normalized == "" || strings.Contains(s.text, " "+normalized+" ") {
+		return true
+	}
+	var words, found int
+	for _, word := range strings.Fields(normalized) {
+		if ignore[word] {
+			continue
+		}
+		words++
+		if s.words[word] {
+			found++
+		}
+	}
+	return words == 0 || found*3 >= words*2
+}
+
+// missingMetrics returns the figures in text whose number is not in the
+// source. Bare numbers below ten are skipped since CVs often spell them out.
+func (s *groundingSource) missingMetrics(text string) []string {
+	var missing []string
+	for _, token := range strings.Fields(text) {
+		token = strings.Trim(token, `"'()[]`)
+		token = strings.TrimRight(token, ".,;:!?")
+		match := metricPattern.FindStringSubmatch(token)
+		if match == nil {
+			continue
+		}
+		number := strings.ReplaceAll(match[1], ",", "")
+		if len(number) == 1 && match[2] == "" && !strings.ContainsAny(token, "$€£") {
+			continue
+		}
+		if !s.numbers[number] {
+			missing = append(missing, token)
+		}
+	}
+	return missing
+}
+
+// sentences splits text after sentence punctuation that is followed by a
+// space, so decimals such as "1.5M" stay intact.
+func sentences(text string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		if strings.IndexByte(".!?", text[i]) >= 0 && (i+1 == len(text) || text[i+1] == ' ') {
+			parts = append(parts, text[start:i+1])
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		parts = append(parts, rest)
+	}
+	return parts
+}
+
+func dateYear(date string) string {
+	return yearPattern.FindString(date)
+}
+
+// normalizeForMatch lowercases text, strips accents and replaces everything
+// but letters and digits with single spaces.
+func normalizeForMatch(text string) string {
+	// transformers keep state, so build a fresh chain per call
+	stripMarks := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	if folded, _, err := transform.String(stripMarks, text); err == nil {
+		text = folded
+	}
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
@@ -0,0 +1,194 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/dtos"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// maxRepairAttempts bounds how many times an invalid resume is sent back to
+// the model for correction before the request fails.
+const maxRepairAttempts = 2
+
+// maxReportedProblems caps the problems quoted in a repair prompt.
+const maxReportedProblems = 20
+
+//go:embed schemas/resume.schema.json
+var resumeSchemaJSON []byte
+
+var resumeSchema = sync.OnceValues(func() (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(resumeSchemaJSON))
+	if err != nil {
+		return nil, fmt.Errorf("parsing resume schema: %w", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("resume.schema.json", doc); err != nil {
+		return nil, fmt.Errorf("loading resume schema: %w", err)
+	}
+	schema, err := compiler.Compile("resume.schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("compiling resume schema: %w", err)
+	}
+	return schema, nil
+})
+
+// ValidationError is one problem found in the model's resume JSON. Path is a
+// JSON pointer into the response, e.g. "/experiences/0/endDate".
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ResumeValidationError reports a resume that was still invalid after the
+// repair attempts ran out.
+type ResumeValidationError struct {
+	Attempts int
+	Errors   []ValidationError
+}
+
+func (e *ResumeValidationError) Error() string {
+	return fmt.Sprintf("AI response failed validation after %d attempts (%d problems, first: %s)",
+		e.Attempts, len(e.Errors), e.Errors[0])
+}
+
+func (e ValidationError) String() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// validateResumeJSON parses raw and checks it against the resume schema and
+// the date conventions. It returns the resume only when there are no problems.
+func validateResumeJSON(raw string) (*dtos.Resume, []ValidationError, error) {
+	schema, err := resumeSchema()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(strings.NewReader(raw))
+	if err != nil {
+		return nil, []ValidationError{{Message: "response is not valid JSON: " + err.Error()}}, nil
+	}
+	if err := schema.Validate(instance); err != nil {
+		verr, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			return nil, nil, fmt.Errorf("validating resume: %w", err)
+		}
+		return nil, schemaProblems(verr), nil
+	}
+
+	var resume dtos.Resume
+	if err := json.Unmarshal([]byte(raw), &resume); err != nil {
+		return nil, []ValidationError{{Message: "response does not match the resume structure: " + err.Error()}}, nil
+	}
+	if problems := dateOrderProblems(&resume); len(problems) > 0 {
+		return nil, problems, nil
+	}
+	return &resume, nil, nil
+}
+
+// schemaProblems flattens a schema validation error into its leaf causes.
+func schemaProblems(verr *jsonschema.ValidationError) []ValidationError {
+	printer := message.NewPrinter(language.English)
+	var problems []ValidationError
+	var walk func(*jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			msg := e.ErrorKind.LocalizedString(printer)
+			// the schema only uses patterns for dates; name the convention instead of the regexp
+			if pattern, ok := e.ErrorKind.(*kind.Pattern); ok {
+				msg = fmt.Sprintf("%q is not a \"MMM YYYY\" date", pattern.Got)
+			}
+			problems = append(problems, ValidationError{
+				Path:    jsonPointer(e.InstanceLocation),
+				Message: msg,
+			})
+			return
+		}
+		for _, cause := range e.Causes {
+			walk(cause)
+		}
+	}
+	walk(verr)
+	return problems
+}
+
+func jsonPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		token = strings.ReplaceAll(token, "~", "~0")
+		b.WriteString("/" + strings.ReplaceAll(token, "/", "~1"))
+	}
+	return b.String()
+}
+
+// dateOrderProblems flags entries whose end date is before their start date.
+func dateOrderProblems(resume *dtos.Resume) []ValidationError {
+	var problems []ValidationError
+	check := func(path, start, end string) {
+		from, ok := parseResumeDate(start)
+		if !ok {
+			return
+		}
+		to, ok := parseResumeDate(end)
+		if ok && to.Before(from) {
+			problems = append(problems, ValidationError{
+				Path:    path + "/endDate",
+				Message: fmt.Sprintf("end date %q is before start date %q", end, start),
+			})
+		}
+	}
+	for i, exp := range resume.Experiences {
+		check(fmt.Sprintf("/experiences/%d", i), exp.StartDate, exp.EndDate)
+	}
+	for i, edu := range resume.Education {
+		check(fmt.Sprintf("/education/%d", i), edu.StartDate, edu.EndDate)
+	}
+	return problems
+}
+
+// parseResumeDate reads a schema-valid date; "Present" is the current month.
+func parseResumeDate(value string) (time.Time, bool) {
+	switch value {
+	case "":
+		return time.Time{}, false
+	case "Present":
+		return time.Now(), true
+	}
+	for _, layout := range []string{"Jan 2006", "2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func resumeRepairPrompt(ctx context.Context, prompts *PromptStore, response string, problems []ValidationError) (string, error) {
+	var list []string
+	for i, problem := range problems {
+		if i == maxReportedProblems {
+			list = append(list, fmt.Sprintf("...and %d more", len(problems)-i))
+			break
+		}
+		list = append(list, problem.String())
+	}
+
+	text, _, err := prompts.render(ctx, promptResumeRepair, struct {
+		Problems []string
+		Schema   string
+		Response string
+	}{list, string(resumeSchemaJSON), response})
+	return text, err
+}
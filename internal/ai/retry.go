@@ -0,0 +1,133 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	maxRetries    = 3
+	retryMaxDelay = 30 * time.Second
+)
+
+// retryBaseDelay is the wait before the first retry; tests shorten it.
+var retryBaseDelay = time.Second
+
+// httpClient is shared by all providers so connections to the LLM APIs are
+// reused. It has no timeout of its own; callers bound each call with the
+// request context instead, which also covers long streamed responses.
+var httpClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 20,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		ForceAttemptHTTP2:   true,
+	},
+}
+
+// doWithRetry sends the request built by newRequest, retrying network
+// errors, 429 and 5xx responses with exponential backoff and jitter, and
+// honouring Retry-After. Every attempt is reported to breaker, and no
+// attempt is made while it is open. Any other response, successful or not,
+// is returned to the caller to handle.
+func doWithRetry(ctx context.Context, vendor string, breaker *CircuitBreaker, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		if err := breaker.Allow(); err != nil {
+			if lastErr != nil {
+				// the breaker opened on this call's own failures, which are
+				// what the caller needs to see
+				return nil, fmt.Errorf("%s: %w after attempt %d failed: %w", vendor, err, attempt-1, lastErr)
+			}
+			return nil, fmt.Errorf("%s: %w", vendor, err)
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			breaker.Release()
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+
+		resp, err := httpClient.Do(req)
+		var retryAfter time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				// the caller gave up; that says nothing about the provider
+				breaker.Release()
+				return nil, fmt.Errorf("sending request to %s API: %w", vendor, err)
+			}
+			breaker.Failure(err)
+			err = fmt.Errorf("sending request to %s API: %w", vendor, err)
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			err = fmt.Errorf("%s API returned status %d: %s", vendor, resp.StatusCode, string(body))
+			// rate limiting means the provider is up, so it does not trip the breaker
+			if resp.StatusCode == http.StatusTooManyRequests {
+				breaker.Success()
+			} else {
+				breaker.Failure(err)
+			}
+		default:
+			breaker.Success()
+			return resp, nil
+		}
+
+		if attempt > maxRetries {
+			return nil, err
+		}
+		lastErr = err
+		delay := retryDelay(attempt, retryAfter)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return nil, fmt.Errorf("not retrying, deadline is too close: %w", err)
+		}
+		log.Printf("%s API attempt %d failed, retrying in %s: %v", vendor, attempt, delay.Round(time.Millisecond), err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("waiting to retry %s API: %w", vendor, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDelay doubles from retryBaseDelay with jitter in the upper half of the
+// delay, unless the server asked for a specific wait.
+func retryDelay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, retryMaxDelay)
+	}
+	delay := retryBaseDelay
+	for i := 1; i < attempt && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, retryMaxDelay)
+	half := delay / 2
+	return half + rand.N(half)
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
@@ -0,0 +1,131 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		min, max time.Duration
+	}{
+		{"missing", "", 0, 0},
+		{"seconds", "5", 5 * time.Second, 5 * time.Second},
+		{"zero", "0", 0, 0},
+		{"negative", "-3", 0, 0},
+		{"garbage", "soon", 0, 0},
+		{"date", time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), 8 * time.Second, 10 * time.Second},
+		{"past date", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), -2 * time.Minute, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+				t.Errorf("parseRetryAfter(%q) = %s, want between %s and %s", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	if got := retryDelay(1, 3*time.Second); got != 3*time.Second {
+		t.Errorf("retryDelay with Retry-After = %s, want 3s", got)
+	}
+	if got := retryDelay(1, time.Hour); got != retryMaxDelay {
+		t.Errorf("retryDelay with a long Retry-After = %s, want %s", got, retryMaxDelay)
+	}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 10: retryMaxDelay} {
+		if got := retryDelay(attempt, 0); got < want/2 || got >= want {
+			t.Errorf("retryDelay(%d) = %s, want in [%s, %s)", attempt, got, want/2, want)
+		}
+	}
+}
+
+// replay serves statuses in turn, repeating the last, and counts the requests.
+func replay(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		w.WriteHeader(statuses[min(n, len(statuses))-1])
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestDoWithRetry(t *testing.T) {
+	saved := retryBaseDelay
+	retryBaseDelay = time.Millisecond
+	t.Cleanup(func() { retryBaseDelay = saved })
+
+	tests := []struct {
+		name      string
+		statuses  []int
+		threshold int
+		calls     int32
+		status    int
+		err       string
+		open      bool
+		state     CircuitState
+	}{
+		{"success", []int{200}, 5, 1, 200, "", false, CircuitClosed},
+		{"client error is not retried", []int{400}, 5, 1, 400, "", false, CircuitClosed},
+		{"recovers", []int{503, 502, 200}, 5, 3, 200, "", false, CircuitClosed},
+		{"gives up after the retries", []int{500}, 5, maxRetries + 1, 0, "status 500", false, CircuitClosed},
+		{"rate limiting does not trip the breaker", []int{429}, 2, maxRetries + 1, 0, "status 429", false, CircuitClosed},
+		{"tripping keeps the provider's error", []int{503}, 2, 2, 0, "status 503", true, CircuitOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := replay(t, tt.statuses...)
+			breaker := NewCircuitBreaker(tt.threshold, time.Minute)
+			resp, err := doWithRetry(context.Background(), "test", breaker, func() (*http.Request, error) {
+				return http.NewRequest(http.MethodPost, server.URL, nil)
+			})
+
+			if got := calls.Load(); got != tt.calls {
+				t.Errorf("calls = %d, want %d", got, tt.calls)
+			}
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("doWithRetry: %v", err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != tt.status {
+					t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("doWithRetry = %v, want an error containing %q", err, tt.err)
+			}
+			if got := errors.Is(err, ErrCircuitOpen); got != tt.open {
+				t.Errorf("errors.Is(err, ErrCircuitOpen) = %t, want %t", got, tt.open)
+			}
+			if got := breaker.Snapshot().State; got != tt.state {
+				t.Errorf("breaker = %s, want %s", got, tt.state)
+			}
+		})
+	}
+}
+
+func TestDoWithRetryOpenBreaker(t *testing.T) {
+	server, calls := replay(t, 200)
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.Failure(errors.New("status 503"))
+
+	_, err := doWithRetry(context.Background(), "test", breaker, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodPost, server.URL, nil)
+	})
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("doWithRetry = %v, want %v", err, ErrCircuitOpen)
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("calls = %d, want none while the breaker is open", got)
+	}
+}
@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// TokenLimits are a model's context window, shared by prompt and completion,
+// and the most it will write in one completion.
+type TokenLimits struct {
+	Context int `json:"context"`
+	Output  int `json:"output"`
+}
+
+// Defaults for models without a configured limit. Ollama only uses a large
+// window when asked to, so its default is what we request as num_ctx.
+var (
+	deepSeekTokenLimits = TokenLimits{Context: 65536, Output: 8192}
+	openAITokenLimits   = TokenLimits{Context: 128000, Output: 16384}
+	ollamaTokenLimits   = TokenLimits{Context: 8192, Output: 8192}
+)
+
+// Room left for the completion when checking roast and letter prompts.
+const (
+	roastOutputTokens  = 2048
+	letterOutputTokens = 1536
+)
+
+// InputTooLargeError reports input that cannot be sent to the model, either
+// because it exceeds MAX_CV_TOKENS or because it cannot fit the context
+// window even when split.
+type InputTooLargeError struct {
+	What   string
+	Tokens int
+	Limit  int
+}
+
+func (e *InputTooLargeError) Error() string {
+	return fmt.Sprintf("%s is too long: about %d tokens, limit is %d", e.What, e.Tokens, e.Limit)
+}
+
+// EstimateTokens approximates how many tokens text costs without a
+// tokenizer: about four characters per token for ASCII, one per character
+// for everything else, plus a tenth for safety. It overestimates accented
+// Latin text, which errs on the side of splitting early.
+func EstimateTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	tokens := (ascii+3)/4 + other
+	return tokens + tokens/10
+}
+
+// CheckInputSize rejects CV text over limit tokens; a limit of zero or less
+// disables the check.
+func CheckInputSize(text string, limit int) error {
+	if limit <= 0 {
+		return nil
+	}
+	if tokens := EstimateTokens(text); tokens > limit {
+		return &InputTooLargeError{What: "CV", Tokens: tokens, Limit: limit}
+	}
+	return nil
+}
+
+// checkPromptBudget makes sure prompt and a completion of up to reserve
+// tokens fit provider's context window, so long input fails clearly instead
+// of being truncated by the model server. The error is about content, the
+// part of the prompt named what.
+func checkPromptBudget(provider LLMProvider, what, content, prompt string, reserve int) error {
+	limits := provider.TokenLimits()
+	overflow := EstimateTokens(prompt) - (limits.Context - min(reserve, limits.Output))
+	if overflow <= 0 {
+		return nil
+	}
+	tokens := EstimateTokens(content)
+	return &InputTooLargeError{What: what, Tokens: tokens, Limit: max(tokens-overflow, 0)}
+}
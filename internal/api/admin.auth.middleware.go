@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+func adminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth := c.GetHeader("Authorization")
+		apiKey := os.Getenv("BURNISHED_ADMIN_API_KEY")
+		if apiKey == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin API not enabled"})
+			c.Abort()
+			return
+		}
+		expectedAuth := "Bearer " + apiKey
+		if auth != expectedAuth {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/utils"
+	"github.com/Emmanuella-codes/burnished-microservice/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) listDeadLettersHandler(c *gin.Context) {
+	deliveries, err := s.webhooks.DeadLetters().List()
+	if err != nil {
+		utils.LogError("Failed to list dead-lettered webhooks", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list dead letters"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries, "count": len(deliveries)})
+}
+
+func (s *Server) getDeadLetterHandler(c *gin.Context) {
+	delivery, err := s.webhooks.DeadLetters().Get(c.Param("id"))
+	if errors.Is(err, webhook.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+	if err != nil {
+		utils.LogError("Failed to read dead-lettered webhook", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read dead letter"})
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
+
+func (s *Server) redeliverWebhookHandler(c *gin.Context) {
+	delivery, err := s.webhooks.Redeliver(c.Param("id"))
+	if errors.Is(err, webhook.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+	if err != nil {
+		utils.LogError("Failed to redeliver webhook", err, "deliveryID", c.Param("id"))
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	utils.LogInfo("Webhook requeued", "deliveryID", delivery.ID)
+	c.JSON(http.StatusAccepted, gin.H{"id": delivery.ID, "status": "queued"})
+}
+
+func (s *Server) listPromptsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"prompts": s.prompts.List()})
+}
+
+// experimentsHandler reports the configured prompt experiments and the
+// outcomes of each variant since the service started.
+func (s *Server) experimentsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"experiments": s.prompts.Experiments(),
+		"variants":    s.prompts.ExperimentStats(),
+	})
+}
+
+// reloadPromptsHandler rereads PROMPTS_DIR straight away instead of waiting
+// for the next poll; a template that fails to parse leaves the old prompts in use.
+func (s *Server) reloadPromptsHandler(c *gin.Context) {
+	if err := s.prompts.Reload(); err != nil {
+		utils.LogError("Failed to reload prompts", err)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"prompts": s.prompts.List()})
+}
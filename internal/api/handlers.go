@@ -2,16 +2,23 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	// "encoding/json"
 	"log"
+	"mime"
 
 	"fmt"
 	"io"
 	"net/http"
-	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Emmanuella-codes/burnished-microservice/internal/ai"
+	"github.com/Emmanuella-codes/burnished-microservice/internal/config"
 	"github.com/Emmanuella-codes/burnished-microservice/internal/documents"
 	"github.com/Emmanuella-codes/burnished-microservice/internal/dtos"
 	"github.com/Emmanuella-codes/burnished-microservice/internal/utils"
@@ -22,10 +29,37 @@ import (
 type ProcessingStatus string
 
 const (
-	StatusCompleted ProcessingStatus = "completed"
-	StatusFailed    ProcessingStatus = "failed"
+	StatusQueued     ProcessingStatus = "queued"
+	StatusProcessing ProcessingStatus = "processing"
+	StatusCompleted  ProcessingStatus = "completed"
+	StatusFailed     ProcessingStatus = "failed"
 )
 
+// Codes of errors about the uploaded file, so clients can tell a file of the
+// wrong type from one that is damaged or password protected.
+const (
+	CodeUnsupportedFormat = "unsupported_format"
+	CodeFormatMismatch    = "format_mismatch"
+	CodeCorruptFile       = "corrupt_file"
+	CodeEncryptedFile     = "encrypted_file"
+)
+
+// fileErrorCode is the code of an error about the uploaded file, or "" when
+// err is about something else.
+func fileErrorCode(err error) string {
+	switch {
+	case errors.Is(err, documents.ErrEncryptedFile):
+		return CodeEncryptedFile
+	case errors.Is(err, documents.ErrCorruptFile):
+		return CodeCorruptFile
+	case errors.Is(err, documents.ErrFormatMismatch):
+		return CodeFormatMismatch
+	case errors.Is(err, documents.ErrUnsupportedFormat):
+		return CodeUnsupportedFormat
+	}
+	return ""
+}
+
 type ProcessCVRequest struct {
 	File           []byte `form:"file"`
 	Filename       string `form:"filename"`
@@ -35,12 +69,58 @@ type ProcessCVRequest struct {
 }
 
 type ProcessResponse struct {
-	// DocumentID  			string  					`json:"documentID"`
-	Status          ProcessingStatus `json:"status"`
-	FormattedResume *dtos.Resume     `json:"formattedResume,omitempty"`
-	CoverLetter     string           `json:"coverLetter,omitempty"`
-	Feedback        string           `json:"feedback,omitempty"`
-	Error           string           `json:"error,omitempty"`
+	DocumentID      string            `json:"documentID,omitempty"`
+	Status          ProcessingStatus  `json:"status"`
+	FormattedResume *dtos.Resume      `json:"formattedResume,omitempty"`
+	CoverLetter     string            `json:"coverLetter,omitempty"`
+	Letter          *dtos.CoverLetter `json:"letter,omitempty"`
+	Feedback        string            `json:"feedback,omitempty"`
+	Error           string            `json:"error,omitempty"`
+	// Code is set when the error is about the uploaded file, e.g.
+	// "corrupt_file".
+	Code string `json:"code,omitempty"`
+
+	// Warnings lists resume items that do not appear in the uploaded CV.
+	Warnings []ai.Warning `json:"warnings,omitempty"`
+	// ValidationErrors lists what was still wrong with the model's resume
+	// when it could not be repaired.
+	ValidationErrors []ai.ValidationError `json:"validationErrors,omitempty"`
+
+	Document *documents.RenderedDocument `json:"document,omitempty"`
+
+	// Provider and Model answered the last LLM call; Fallback is set when
+	// that was not the first model in the mode's chain.
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Fallback bool   `json:"fallback,omitempty"`
+	// Prompts maps each prompt sent to its version. A cached result sends
+	// no prompt, so it has none, nor Variants.
+	Prompts map[string]string `json:"prompts,omitempty"`
+	// Variants maps each prompt under an A/B experiment to the variant sent.
+	Variants map[string]string `json:"variants,omitempty"`
+	// Cached is set when the model's result was reused from an identical
+	// earlier request.
+	Cached bool `json:"cached,omitempty"`
+
+	// timedOut marks a failure caused by the mode's deadline.
+	timedOut bool
+	// tooLarge is set when the CV or job description did not fit the model.
+	tooLarge *ai.InputTooLargeError
+}
+
+// processInput is a validated /process or /jobs submission.
+type processInput struct {
+	Mode           string
+	JobDescription string
+	Format         string
+	FileData       []byte
+	Output         string
+	Template       *documents.ResumeTemplate
+	Fabrication    ai.FabricationPolicy
+	NoCache        bool
+	// Subject keeps prompt experiment assignments sticky: the userId field,
+	// else the caller's API key.
+	Subject string
 }
 
 func (s *Server) healthHandler(c *gin.Context) {
@@ -49,15 +129,171 @@ func (s *Server) healthHandler(c *gin.Context) {
 		"time":   time.Now().Format(time.RFC3339),
 	}
 
+	routes := gin.H{}
+	for _, mode := range config.RoutedModes {
+		var chain []gin.H
+		for _, provider := range s.models.For(mode).Providers() {
+			entry := gin.H{
+				"provider": provider.Name(),
+				"model":    provider.Model(),
+			}
+			if reporter, ok := provider.(ai.CircuitReporter); ok {
+				circuit := reporter.Circuit()
+				entry["circuit"] = circuit
+				// the service itself is up, so this stays 200; only LLM calls fail fast
+				if circuit.State != ai.CircuitClosed {
+					response["status"] = "degraded"
+				}
+			}
+			chain = append(chain, entry)
+		}
+		routes[mode] = chain
+	}
+	response["llm"] = routes
+
 	c.JSON(http.StatusOK, response)
 }
 
 func (s *Server) processCVHandler(c *gin.Context) {
+	input, ok := s.bindProcessInput(c)
+	if !ok {
+		return
+	}
+
+	log.Println("starting ParseCV...")
+
+	response := s.process(c.Request.Context(), input, nil)
+	if err := s.sendWebhook(response); err != nil {
+		utils.LogError("Failed to send webhook", err)
+	}
+	if s.ctx.Err() != nil && response.Status == StatusFailed {
+		// cancelled by the shutdown, not the client, who is still waiting
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Server is shutting down, try again later"})
+		return
+	}
+	if c.Request.Context().Err() != nil && !response.timedOut {
+		utils.LogInfo("Request cancelled before completion", "mode", input.Mode)
+		return
+	}
+	if response.Status == StatusFailed {
+		if response.timedOut {
+			c.JSON(http.StatusGatewayTimeout, gin.H{"error": response.Error})
+			return
+		}
+		if response.tooLarge != nil {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error":  response.Error,
+				"tokens": response.tooLarge.Tokens,
+				"limit":  response.tooLarge.Limit,
+			})
+			return
+		}
+		if response.Code != "" {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error": response.Error,
+				"code":  response.Code,
+			})
+			return
+		}
+		if len(response.Warnings) > 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":    response.Error,
+				"warnings": response.Warnings,
+			})
+			return
+		}
+		if len(response.ValidationErrors) > 0 {
+			c.JSON(http.StatusBadGateway, gin.H{
+				"error":            response.Error,
+				"validationErrors": response.ValidationErrors,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": response.Error})
+		return
+	}
+	if response.Document != nil {
+		c.Header("Content-Disposition", contentDisposition(response.Document.Filename))
+		c.Data(http.StatusOK, response.Document.ContentType, response.Document.Content)
+		utils.LogInfo("Document sent successfully", "filename", response.Document.Filename)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+	utils.LogInfo("Response sent successfully")
+}
+
+// contentDisposition names a download. Names outside ASCII, such as
+// "Adébáyọ̀-resume.pdf", are sent as an RFC 2231 filename* parameter.
+func contentDisposition(filename string) string {
+	if value := mime.FormatMediaType("attachment", map[string]string{"filename": filename}); value != "" {
+		return value
+	}
+	return "attachment"
+}
+
+// createJobHandler queues a CV for background processing and returns its job ID
+// straight away; clients poll getJobHandler or wait for the webhook.
+func (s *Server) createJobHandler(c *gin.Context) {
+	input, ok := s.bindProcessInput(c)
+	if !ok {
+		return
+	}
+
+	job, err := s.jobs.Submit(input)
+	if err != nil {
+		utils.LogError("Failed to queue job", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	utils.LogInfo("Job queued", "jobID", job.DocumentID, "mode", input.Mode)
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (s *Server) listTemplatesHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"templates": s.templates.Names(),
+		"default":   documents.DefaultTemplate,
+	})
+}
+
+func (s *Server) getJobHandler(c *gin.Context) {
+	job, ok := s.jobs.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// getJobDocumentHandler downloads the file a finished job rendered.
+func (s *Server) getJobDocumentHandler(c *gin.Context) {
+	job, ok := s.jobs.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if job.Document == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job has no document"})
+		return
+	}
+	c.Header("Content-Disposition", contentDisposition(job.Document.Filename))
+	c.Data(http.StatusOK, job.Document.ContentType, job.Document.Content)
+}
+
+// jobDocumentPath is where getJobDocumentHandler serves the job's file.
+func jobDocumentPath(id string) string {
+	return "/api/v1/jobs/" + id + "/document"
+}
+
+// bindProcessInput validates the multipart form shared by /process and /jobs.
+// It writes the error response itself and reports false when the request is invalid.
+func (s *Server) bindProcessInput(c *gin.Context) (*processInput, bool) {
 	// parse multipart form
 	if err := c.Request.ParseMultipartForm(s.cfg.MaxFileSize); err != nil {
 		utils.LogError("Failed to parse multipart form", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse form: " + err.Error()})
-		return
+		return nil, false
 	}
 
 	mode := c.PostForm("mode")
@@ -65,147 +301,216 @@ func (s *Server) processCVHandler(c *gin.Context) {
 	if mode != "roast" && mode != "format" && mode != "letter" {
 		utils.LogInfo("Invalid mode", "mode", mode)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be 'roast' or 'format' or 'letter'"})
-		return
+		return nil, false
 	}
 
 	jobDescription := c.PostForm("jobDescription")
 	if mode == "format" && jobDescription == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "jobDescription is required for format mode"})
-		return
+		return nil, false
 	}
 	if mode == "letter" && jobDescription == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "jobDescription is required for letter mode"})
-		return
+		return nil, false
+	}
+
+	output := c.DefaultPostForm("output", documents.OutputJSON)
+	if output != documents.OutputJSON && output != documents.OutputPDF && output != documents.OutputDOCX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "output must be 'json', 'pdf' or 'docx'"})
+		return nil, false
+	}
+	if output != documents.OutputJSON && mode == "roast" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "output is only supported for format and letter modes"})
+		return nil, false
+	}
+
+	fabrication, err := ai.ParseFabricationPolicy(c.DefaultPostForm("fabrication", s.cfg.FabricationPolicy))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	noCache, err := strconv.ParseBool(c.DefaultPostForm("noCache", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "noCache must be 'true' or 'false'"})
+		return nil, false
+	}
+
+	// the key is hashed so bearer tokens are never held as map keys
+	key := sha256.Sum256([]byte(c.GetHeader("Authorization")))
+	subject := "key:" + hex.EncodeToString(key[:])
+	if userID := strings.TrimSpace(c.PostForm("userId")); userID != "" {
+		subject = "user:" + userID
+	}
+
+	tmpl, ok := s.templates.Get(c.PostForm("template"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Unknown template",
+			"templates": s.templates.Names(),
+		})
+		return nil, false
 	}
 
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
-		return
+		return nil, false
 	}
 	defer file.Close()
 
-	// validate file type
-	ext := filepath.Ext(header.Filename)
-	if ext != ".pdf" && ext != ".docx" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported file type; only PDF and DOCX are allowed"})
-		return
-	}
-
 	// check file size
 	if header.Size > s.cfg.MaxFileSize {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": fmt.Sprintf("File size exceeds limit: %d bytes", s.cfg.MaxFileSize),
 		})
-		return
+		return nil, false
 	}
 
 	// read file
 	fileData, err := io.ReadAll(file)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
-		return
+		return nil, false
+	}
+
+	// validate file type by its content; the extension only has to agree
+	format, err := s.formats.Detect(fileData, header.Filename)
+	if err != nil {
+		utils.LogInfo("Rejected file", "filename", header.Filename, "error", err.Error())
+		code := fileErrorCode(err)
+		switch code {
+		case CodeUnsupportedFormat:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":      "Unsupported file type",
+				"code":       code,
+				"extensions": s.formats.Extensions(),
+			})
+		case CodeFormatMismatch:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": code})
+		default:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": code})
+		}
+		return nil, false
 	}
 
-	// prepare webhook response
-	var response ProcessResponse
+	return &processInput{
+		Mode:           mode,
+		JobDescription: jobDescription,
+		Format:         format.Name,
+		FileData:       fileData,
+		Output:         output,
+		Template:       tmpl,
+		Fabrication:    fabrication,
+		NoCache:        noCache,
+		Subject:        subject,
+	}, true
+}
+
+// process runs the requested mode and reports the outcome in the returned
+// response rather than as an error, so callers can forward it as-is. Roast
+// and letter text is passed to onDelta as it is generated when it is set.
+func (s *Server) process(ctx context.Context, input *processInput, onDelta func(string)) (response ProcessResponse) {
 	response.Status = StatusCompleted
 
-	log.Println("starting ParseCV...")
+	timeout := s.modeTimeout(input.Mode)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ctx, trace := ai.WithTrace(ai.WithSubject(ctx, input.Subject))
+	defer func() {
+		response.Provider, response.Model, response.Fallback = trace.Served()
+		response.Prompts = trace.Prompts()
+		response.Variants = trace.Variants()
+		if response.Status == StatusFailed && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			response.timedOut = true
+			response.Error = fmt.Sprintf("%s mode timed out after %s: %s", input.Mode, timeout, response.Error)
+		}
+	}()
 
 	// process based on mode
-	switch mode {
+	switch input.Mode {
 	case "format":
-		fileReader := bytes.NewReader(fileData)
-		resume, err := s.docProc.FormatForATS(fileReader, ext, jobDescription)
+		fileReader := bytes.NewReader(input.FileData)
+		resume, warnings, cached, err := s.docProc.FormatForATS(ctx, fileReader, input.Format, input.JobDescription, input.Fabrication, input.NoCache)
 		if err != nil {
 			response.Status = StatusFailed
 			response.Error = "Failed to format CV: " + err.Error()
-			if err := s.sendWebhook(response); err != nil {
-				utils.LogError("Failed to send webhook", err)
+			errors.As(err, &response.tooLarge)
+			response.Code = fileErrorCode(err)
+			var invalid *ai.ResumeValidationError
+			if errors.As(err, &invalid) {
+				response.ValidationErrors = invalid.Errors
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": response.Error})
-			return
+			var fabricated *ai.FabricationError
+			if errors.As(err, &fabricated) {
+				response.Warnings = fabricated.Warnings
+			}
+			return response
 		}
-
 		response.FormattedResume = resume
+		response.Warnings = warnings
+		response.Cached = cached
 
-		if err := s.sendWebhook(response); err != nil {
-			utils.LogError("Failed to send webhook", err)
+		if input.Output != documents.OutputJSON {
+			document, err := documents.RenderResume(resume, input.Output, input.Template)
+			if err != nil {
+				response.Status = StatusFailed
+				response.Error = "Failed to render CV: " + err.Error()
+				return response
+			}
+			response.Document = document
 		}
-		c.JSON(http.StatusOK, response)
-		utils.LogInfo("Response sent successfully")
 
 	case "roast":
-		fileReader := bytes.NewReader(fileData)
-		feedback, err := s.docProc.RoastCV(fileReader, ext)
+		fileReader := bytes.NewReader(input.FileData)
+		feedback, cached, err := s.docProc.RoastCV(ctx, fileReader, input.Format, input.NoCache, onDelta)
 		if err != nil {
 			response.Status = StatusFailed
 			response.Error = "Failed to roast CV: " + err.Error()
-			if err := s.sendWebhook(response); err != nil {
-				utils.LogError("Failed to send webhook", err)
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": response.Error})
-			return
+			errors.As(err, &response.tooLarge)
+			response.Code = fileErrorCode(err)
+			return response
 		}
 		response.Feedback = feedback
-
-		if err := s.sendWebhook(response); err != nil {
-			utils.LogError("Failed to send webhook", err)
-		}
-		c.JSON(http.StatusOK, response)
-		utils.LogInfo("Response sent successfully")
+		response.Cached = cached
 
 	case "letter":
-		var processor documents.DocumentProcessor
-		switch ext {
-		case ".pdf":
-			processor = documents.NewPDFProcessor()
-		case ".docx":
-			processor = documents.NewDOCXProcessor()
-		default:
-			response.Status = StatusFailed
-			response.Error = fmt.Sprintf("Unsupported file format: %s", ext)
-			if err := s.sendWebhook(response); err != nil {
-				utils.LogError("Failed to send webhook", err)
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": response.Error})
-			return
-		}
-
-		fileReader := bytes.NewReader(fileData)
-		cvText, err := processor.ExtractText(fileReader)
-		if err != nil {
-			response.Status = StatusFailed
-			response.Error = fmt.Sprintf("Failed to extract text from CV: %v", err)
-			log.Printf("Text extraction failed: %v", err)
-			if err := s.sendWebhook(response); err != nil {
-				utils.LogError("Failed to send webhook", err)
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": response.Error})
-			return
-		}
-
-		log.Printf("Extracted %d characters from CV", len(cvText))
-
-		coverLetter, err := ai.GenerateCoverLetter(cvText, jobDescription, s.cfg.DeepSeekAPIKey)
+		fileReader := bytes.NewReader(input.FileData)
+		coverLetter, cached, err := s.docProc.WriteCoverLetter(ctx, fileReader, input.Format, input.JobDescription, input.NoCache, onDelta)
 		if err != nil {
 			response.Status = StatusFailed
 			response.Error = fmt.Sprintf("Failed to generate cover letter: %v", err)
+			errors.As(err, &response.tooLarge)
+			response.Code = fileErrorCode(err)
 			utils.LogError("Cover letter generation failed", err)
-			if err := s.sendWebhook(response); err != nil {
-				utils.LogError("Failed to send webhook", err)
+			return response
+		}
+		response.Cached = cached
+		response.Letter = coverLetter
+		response.CoverLetter = documents.CoverLetterBody(coverLetter)
+
+		if input.Output != documents.OutputJSON {
+			document, err := documents.RenderCoverLetter(coverLetter, input.Output, input.Template)
+			if err != nil {
+				response.Status = StatusFailed
+				response.Error = "Failed to render cover letter: " + err.Error()
+				return response
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": response.Error})
-			return
+			response.Document = document
 		}
+	}
 
-		response.CoverLetter = coverLetter
+	return response
+}
 
-		if err := s.sendWebhook(response); err != nil {
-			log.Printf("Failed to send webhook: %v", err)
-		}
-		c.JSON(http.StatusOK, response)
-		utils.LogInfo("Response sent successfully")
+// modeTimeout is the deadline for processing one request in mode.
+func (s *Server) modeTimeout(mode string) time.Duration {
+	switch mode {
+	case "format":
+		return s.cfg.FormatTimeout
+	case "roast":
+		return s.cfg.RoastTimeout
+	default:
+		return s.cfg.LetterTimeout
 	}
 }
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/utils"
+)
+
+var (
+	errQueueFull   = errors.New("job queue is full, try again later")
+	errQueueClosed = errors.New("server is shutting down, try again later")
+)
+
+type job struct {
+	input     *processInput
+	response  ProcessResponse
+	updatedAt time.Time
+}
+
+// JobQueue runs process requests on a fixed pool of workers and keeps their
+// results in memory until they expire.
+type JobQueue struct {
+	ctx     context.Context
+	server  *Server
+	pending chan string
+	ttl     time.Duration
+
+	mu     sync.RWMutex
+	jobs   map[string]*job
+	closed bool
+
+	wg   sync.WaitGroup
+	stop chan struct{}
+}
+
+// NewJobQueue starts the workers. Jobs run under ctx, so cancelling it
+// aborts the ones in progress.
+func NewJobQueue(ctx context.Context, s *Server, workers, size int, ttl time.Duration) *JobQueue {
+	q := &JobQueue{
+		ctx:     ctx,
+		server:  s,
+		pending: make(chan string, size),
+		ttl:     ttl,
+		jobs:    make(map[string]*job),
+		stop:    make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+	go q.evictExpired()
+	return q
+}
+
+// Submit stores a new queued job and hands it to the worker pool.
+func (q *JobQueue) Submit(input *processInput) (ProcessResponse, error) {
+	id, err := newJobID()
+	if err != nil {
+		return ProcessResponse{}, err
+	}
+
+	j := &job{
+		input:     input,
+		response:  ProcessResponse{DocumentID: id, Status: StatusQueued},
+		updatedAt: time.Now(),
+	}
+
+	// the lock keeps Close from draining the queue between the check and
+	// the send, which would strand the job as queued
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ProcessResponse{}, errQueueClosed
+	}
+	select {
+	case q.pending <- id:
+		q.jobs[id] = j
+		return j.response, nil
+	default:
+		return ProcessResponse{}, errQueueFull
+	}
+}
+
+// Get returns a snapshot of the job's current response.
+func (q *JobQueue) Get(id string) (ProcessResponse, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return ProcessResponse{}, false
+	}
+	return j.response, true
+}
+
+// Close stops accepting jobs and drops the queued ones that have not
+// started; they get no webhook. Running jobs carry on, see Wait.
+func (q *JobQueue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.stop)
+	q.mu.Unlock()
+
+	for {
+		select {
+		case id := <-q.pending:
+			q.drop(id)
+		default:
+			return
+		}
+	}
+}
+
+// Wait waits for the running jobs to finish, or for ctx to be done.
+func (q *JobQueue) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *JobQueue) worker() {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case id := <-q.pending:
+			// select picks at random when both are ready
+			select {
+			case <-q.stop:
+				q.drop(id)
+				return
+			default:
+			}
+			q.run(id)
+		}
+	}
+}
+
+// drop forgets a queued job that will not run.
+func (q *JobQueue) drop(id string) {
+	q.mu.Lock()
+	delete(q.jobs, id)
+	q.mu.Unlock()
+	utils.LogWarn("Dropped queued job at shutdown", "jobID", id)
+}
+
+func (q *JobQueue) run(id string) {
+	q.mu.Lock()
+	j, ok := q.jobs[id]
+	if !ok {
+		q.mu.Unlock()
+		return
+	}
+	input := j.input
+	j.response.Status = StatusProcessing
+	j.updatedAt = time.Now()
+	q.mu.Unlock()
+
+	// a panic fails this job instead of taking down the process along with
+	// every queued job and pending webhook
+	defer func() {
+		if r := recover(); r != nil {
+			utils.LogError("Job panicked", fmt.Errorf("%v", r), "jobID", id, "stack", string(debug.Stack()))
+			q.finish(id, j, ProcessResponse{
+				DocumentID: id,
+				Status:     StatusFailed,
+				Error:      fmt.Sprintf("Failed to process CV: %v", r),
+			})
+		}
+	}()
+
+	utils.LogInfo("Job started", "jobID", id, "mode", input.Mode)
+	response := q.server.process(q.ctx, input, nil)
+	response.DocumentID = id
+	q.finish(id, j, response)
+}
+
+// finish stores a job's response and sends it to the webhook.
+func (q *JobQueue) finish(id string, j *job, response ProcessResponse) {
+	q.mu.Lock()
+	j.response = response
+	j.input = nil // release the file bytes
+	j.updatedAt = time.Now()
+	q.mu.Unlock()
+
+	utils.LogInfo("Job finished", "jobID", id, "status", response.Status)
+	if err := q.server.sendWebhook(response); err != nil {
+		utils.LogError("Failed to send webhook", err, "jobID", id)
+	}
+}
+
+// evictExpired drops finished jobs that have not been updated within the TTL.
+func (q *JobQueue) evictExpired() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case now := <-ticker.C:
+			q.mu.Lock()
+			for id, j := range q.jobs {
+				done := j.response.Status == StatusCompleted || j.response.Status == StatusFailed
+				if done && now.Sub(j.updatedAt) > q.ttl {
+					delete(q.jobs, id)
+				}
+			}
+			q.mu.Unlock()
+		}
+	}
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -1,20 +1,24 @@
 package api
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/Emmanuella-codes/burnished-microservice/internal/ai"
+	"github.com/Emmanuella-codes/burnished-microservice/internal/cache"
 	"github.com/Emmanuella-codes/burnished-microservice/internal/config"
 	"github.com/Emmanuella-codes/burnished-microservice/internal/documents"
+	"github.com/Emmanuella-codes/burnished-microservice/internal/utils"
+	"github.com/Emmanuella-codes/burnished-microservice/internal/webhook"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,28 +29,65 @@ type Server struct {
 	server 				*http.Server
 	docProc 			*documents.Processor
 	docFormatter 	*documents.Formatter
-	webhookClient *http.Client
+	webhooks 			*webhook.Dispatcher
+	models 				*ai.Router
+	prompts 			*ai.PromptStore
+	jobs 					*JobQueue
+	templates 		*documents.TemplateRegistry
+	formats 			*documents.FormatRegistry
+
+	// ctx is the parent of every request and job context; cancel aborts
+	// in-flight work on shutdown.
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-func NewServer(cfg *config.Config) *Server {
+func NewServer(cfg *config.Config, models *ai.Router, prompts *ai.PromptStore) *Server {
 	router := gin.Default()
 	pdfProcessor := documents.NewPDFProcessor()
-	processor := documents.NewProcessor(cfg)
+	formats := documents.NewFormatRegistry()
+	processor := documents.NewProcessor(cfg, models, prompts, formats, newResultCache(cfg))
 	formatter := documents.NewFormatter(cfg, pdfProcessor)
 	webhookClient := &http.Client{
 		Timeout: 10 * time.Second,
 	}
+	webhooks := webhook.NewDispatcher(webhook.Options{
+		URL:         cfg.WebhookURL,
+		Secrets:     cfg.WebhookSecrets,
+		Workers:     2,
+		QueueSize:   cfg.JobQueueSize,
+		MaxAttempts: cfg.WebhookMaxAttempts,
+		BaseDelay:   2 * time.Second,
+		MaxDelay:    5 * time.Minute,
+	}, webhookClient, newDeadLetterStore(cfg))
+	templates := documents.NewTemplateRegistry()
+	if cfg.TemplatesDir != "" {
+		if err := templates.LoadDir(cfg.TemplatesDir); err != nil {
+			utils.LogError("Failed to load resume templates", err, "dir", cfg.TemplatesDir)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
 	s := &Server{
 		cfg: cfg,
 		router: router,
 		docProc: processor,
 		docFormatter: formatter,
-		webhookClient: webhookClient,
+		webhooks: webhooks,
+		models: models,
+		prompts: prompts,
+		templates: templates,
+		formats: formats,
+		ctx: ctx,
+		cancel: cancel,
 		server: &http.Server{
 			Addr: 	 ":" + cfg.Port,
 			Handler: router,
+			BaseContext: func(net.Listener) context.Context {
+				return ctx
+			},
 		},
 	}
+	s.jobs = NewJobQueue(ctx, s, cfg.JobWorkers, cfg.JobQueueSize, cfg.JobTTL)
 	s.setupRoutes()
 	return s
 }
@@ -59,6 +100,25 @@ func (s *Server) setupRoutes() {
 	protected := api.Group("")
 	protected.Use(authMiddleware())
 	protected.POST("/process", s.processCVHandler)
+	protected.POST("/process/stream", s.streamProcessHandler)
+	protected.POST("/jobs", s.createJobHandler)
+	protected.GET("/jobs/:id", s.getJobHandler)
+	protected.GET("/jobs/:id/document", s.getJobDocumentHandler)
+	protected.GET("/templates", s.listTemplatesHandler)
+
+	admin := api.Group("/admin")
+	admin.Use(adminAuthMiddleware())
+	admin.GET("/webhooks/dead-letters", s.listDeadLettersHandler)
+	admin.GET("/webhooks/dead-letters/:id", s.getDeadLetterHandler)
+	admin.POST("/webhooks/dead-letters/:id/redeliver", s.redeliverWebhookHandler)
+	admin.GET("/prompts", s.listPromptsHandler)
+	admin.POST("/prompts/reload", s.reloadPromptsHandler)
+	admin.GET("/experiments", s.experimentsHandler)
+}
+
+// Handler serves the API without listening, e.g. from an httptest server.
+func (s *Server) Handler() http.Handler {
+	return s.router
 }
 
 func (s *Server) Start() error {
@@ -66,14 +126,28 @@ func (s *Server) Start() error {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
+	// done is closed once the queued jobs and webhooks are drained, which
+	// Start waits for since ListenAndServe returns as soon as Shutdown begins
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		sig := <-quit
 		log.Printf("Shutdown signal received: %s", sig.String())
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
 		defer cancel()
+		// stop taking work, then give in-flight requests and running jobs
+		// until the deadline to finish
+		s.jobs.Close()
 		if err := s.server.Shutdown(ctx); err != nil {
 			fmt.Printf("Server forced to shutdown: %v\n", err)
 		}
+		if err := s.jobs.Wait(ctx); err != nil {
+			utils.LogWarn("Cancelling jobs still running at the shutdown deadline", "error", err)
+		}
+		// abort what is left; its handlers and jobs answer with an error
+		s.cancel()
+		s.jobs.Wait(context.Background())
+		s.webhooks.Stop()
 	}()
 	
 	fmt.Printf("Server starting on port %s\n", s.cfg.Port)
@@ -81,39 +155,63 @@ func (s *Server) Start() error {
 	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
+	<-done
 	return nil
 }
 
+// sendWebhook queues payload for delivery; retries and dead-lettering
+// happen in the background.
 func (s *Server) sendWebhook(payload ProcessResponse) error {
-	webhookURL := os.Getenv("BURNISHED_WEB_WEBHOOK_URL")
-	if webhookURL == "" {
+	if s.cfg.WebhookURL == "" {
 		return fmt.Errorf("BURNISHED_WEB_WEBHOOK_URL not configured")
 	}
 
+	// a rendered file is not sent in the signed body: a job's can be
+	// downloaded from the link, and /process callers already have theirs
+	if document := payload.Document; document != nil {
+		payload.Document = &documents.RenderedDocument{Filename: document.Filename, ContentType: document.ContentType}
+		if payload.DocumentID != "" {
+			payload.Document.URL = jobDocumentPath(payload.DocumentID)
+		}
+	}
+
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal webhook payload: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", webhookURL, bytes.NewBuffer(body))
+	delivery, err := s.webhooks.Enqueue(body)
 	if err != nil {
-		return fmt.Errorf("failed to create webhook request: %w", err)
+		return fmt.Errorf("failed to queue webhook: %w", err)
 	}
+	log.Printf("Queued webhook %s to: %s", delivery.ID, s.cfg.WebhookURL)
+	return nil
+}
 
-	webhookSecret := os.Getenv("WEBHOOK_SECRET")
-	req.Header.Set("Authorization", "Bearer "+webhookSecret)
-	req.Header.Set("Content-Type", "application/json")
-
-	log.Printf("Sending webhook to: %s", webhookURL)
-	res, err := s.webhookClient.Do(req)
+func newDeadLetterStore(cfg *config.Config) webhook.DeadLetterStore {
+	if cfg.WebhookDeadLetterDir == "" {
+		utils.LogWarn("WEBHOOK_DEAD_LETTER_DIR is not set; dead-lettered webhooks, including those still queued at shutdown, are lost on restart")
+		return webhook.NewMemoryDeadLetterStore()
+	}
+	store, err := webhook.NewFileDeadLetterStore(cfg.WebhookDeadLetterDir)
 	if err != nil {
-		return fmt.Errorf("failed to send webhook: %w", err)
+		utils.LogError("Falling back to in-memory webhook dead letters", err)
+		return webhook.NewMemoryDeadLetterStore()
 	}
-	log.Printf("Webhook response status: %d", res.StatusCode)
-	defer res.Body.Close()
+	return store
+}
 
-	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		return fmt.Errorf("webhook returned non-2xx status: %d", res.StatusCode)
+// newResultCache returns nil when caching is off, so every request calls the model.
+func newResultCache(cfg *config.Config) cache.Cache {
+	switch cfg.CacheBackend {
+	case "off":
+		return nil
+	case "disk":
+		results, err := cache.NewFileCache(cfg.CacheDir, cfg.CacheTTL)
+		if err == nil {
+			return results
+		}
+		utils.LogError("Falling back to in-memory result cache", err, "dir", cfg.CacheDir)
 	}
-	return nil
+	return cache.NewMemoryCache(cfg.CacheMaxEntries, cfg.CacheTTL)
 }
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamDelta is the payload of a "delta" event.
+type streamDelta struct {
+	Text string `json:"text"`
+}
+
+// streamProcessHandler runs roast and letter requests like processCVHandler
+// but answers with Server-Sent Events: a "status" event once the upload is
+// accepted, "delta" events carrying text as the model writes it, and a final
+// "result" event with the complete ProcessResponse, also on failure.
+func (s *Server) streamProcessHandler(c *gin.Context) {
+	input, ok := s.bindProcessInput(c)
+	if !ok {
+		return
+	}
+	if input.Mode == "format" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "streaming is only supported for roast and letter modes"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// stop reverse proxies such as nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+
+	send := func(event string, data any) {
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+	}
+	send("status", gin.H{"status": StatusProcessing})
+
+	response := s.process(c.Request.Context(), input, func(text string) {
+		send("delta", streamDelta{Text: text})
+	})
+	if err := s.sendWebhook(response); err != nil {
+		utils.LogError("Failed to send webhook", err)
+	}
+	send("result", response)
+	utils.LogInfo("Stream completed", "mode", input.Mode, "status", string(response.Status))
+}
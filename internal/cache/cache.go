@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/utils"
+)
+
+// Cache stores results under content-addressed keys until their TTL passes.
+type Cache interface {
+	// Get returns the value stored under key unless it is missing or expired.
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte) error
+}
+
+// Key hashes parts into a cache key. Each part is length-prefixed, so
+// moving text from one part to the next changes the key.
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(strconv.Itoa(len(part)) + ":"))
+		h.Write([]byte(part))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Fetch returns the value cached under key, or computes and caches it. With
+// bypass set the cached value is ignored but the fresh one still replaces
+// it. A nil cache always computes. Cache failures are logged, never returned.
+func Fetch[T any](c Cache, key string, bypass bool, compute func() (T, error)) (value T, cached bool, err error) {
+	if c != nil && !bypass {
+		if data, ok := c.Get(key); ok {
+			if err := json.Unmarshal(data, &value); err == nil {
+				return value, true, nil
+			}
+			utils.LogError("Ignoring unreadable cache entry", err, "key", key)
+		}
+	}
+
+	value, err = compute()
+	if err != nil || c == nil {
+		return value, false, err
+	}
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = c.Set(key, data)
+	}
+	if err != nil {
+		utils.LogError("Failed to cache result", err, "key", key)
+	}
+	return value, false, nil
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often Set sweeps expired files, so entries that are
+// never requested again do not pile up.
+const pruneInterval = time.Hour
+
+// FileCache writes one file per result into a directory and uses the file's
+// modification time for expiry.
+type FileCache struct {
+	dir string
+	ttl time.Duration
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+func NewFileCache(dir string, ttl time.Duration) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+	return &FileCache{dir: dir, ttl: ttl}, nil
+}
+
+func (c *FileCache) Get(key string) ([]byte, bool) {
+	path := c.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if time.Since(info.ModTime()) > c.ttl {
+		os.Remove(path)
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Set writes through a temporary file so a concurrent Get never sees a
+// partly written entry.
+func (c *FileCache) Set(key string, value []byte) error {
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating cache file: %w", err)
+	}
+	_, err = tmp.Write(value)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+
+	c.mu.Lock()
+	prune := time.Since(c.lastPruned) > pruneInterval
+	if prune {
+		c.lastPruned = time.Now()
+	}
+	c.mu.Unlock()
+	if prune {
+		go c.prune()
+	}
+	return nil
+}
+
+// prune removes expired entries, and temporary files left by a crash.
+func (c *FileCache) prune() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) <= c.ttl {
+			continue
+		}
+		os.Remove(filepath.Join(c.dir, entry.Name()))
+	}
+}
+
+// path is safe for any key, since keys from Key are hex digests.
+func (c *FileCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
@@ -4,27 +4,159 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderDeepSeek = "deepseek"
+	ProviderOpenAI   = "openai"
+	ProviderOllama   = "ollama"
 )
 
 type Config struct {
 	Port           string
 	DeepSeekAPIKey string
 	MaxFileSize    int64
+
+	// unioffice needs a license key to read or write DOCX files
+	UniOfficeLicenseKey string
+	// optional directory of extra resume templates (*.json)
+	TemplatesDir string
+	// optional directory of fallback TTF fonts for PDF output
+	PDFFontsDir string
+	// optional directory of prompt templates replacing the embedded ones,
+	// polled for changes every PromptsReloadInterval (0 disables polling)
+	PromptsDir            string
+	PromptsReloadInterval time.Duration
+	// prompt A/B experiments: weighted variants per prompt name
+	PromptVariants map[string][]PromptVariant
+
+	// LLM backend selection; model and base URL fall back to provider defaults.
+	LLMProvider string
+	LLMModel    string
+	LLMBaseURL  string
+	LLMAPIKey   string
+
+	// per-provider base URLs; LLM_BASE_URL fills in the one for LLM_PROVIDER
+	DeepSeekBaseURL string
+	OpenAIBaseURL   string
+	OllamaBaseURL   string
+
+	// ordered fallback chain of models for each mode in RoutedModes
+	ModelRoutes map[string][]ModelRoute
+	// context window overrides keyed by model name
+	ModelTokenLimits map[string]ModelTokenLimit
+	// largest CV, in estimated tokens, accepted for any mode
+	MaxCVTokens int
+
+	// what to do with resume items not found in the uploaded CV: warn, strip or reject
+	FabricationPolicy string
+
+	// result cache: memory, disk or off
+	CacheBackend    string
+	CacheDir        string
+	CacheTTL        time.Duration
+	CacheMaxEntries int
+
+	// background job processing
+	JobWorkers   int
+	JobQueueSize int
+	JobTTL       time.Duration
+	// how long shutdown waits for in-flight requests and running jobs
+	// before cancelling them
+	ShutdownTimeout time.Duration
+
+	// per-mode deadlines for a whole request, including text extraction and every LLM call
+	FormatTimeout time.Duration
+	RoastTimeout  time.Duration
+	LetterTimeout time.Duration
+
+	// webhook delivery
+	WebhookURL           string
+	WebhookSecrets       []string // current secret first, then the one being retired
+	WebhookMaxAttempts   int
+	WebhookDeadLetterDir string
 }
 
 func Load() (*Config, error) {
 	cfg := &Config{
-		Port:        "8080",
-		MaxFileSize: 10 * 1024 * 1024, // Default: 10MB.
+		Port:         "8080",
+		MaxFileSize:  10 * 1024 * 1024, // Default: 10MB.
+		LLMProvider:  ProviderDeepSeek,
+		JobWorkers:   4,
+		JobQueueSize: 100,
+		JobTTL:       time.Hour,
+
+		ShutdownTimeout: 30 * time.Second,
+
+		CacheBackend:    "memory",
+		CacheDir:        "cache",
+		CacheTTL:        24 * time.Hour,
+		CacheMaxEntries: 1000,
+
+		PromptsReloadInterval: 30 * time.Second,
+
+		FormatTimeout: 3 * time.Minute,
+		RoastTimeout:  2 * time.Minute,
+		LetterTimeout: 2 * time.Minute,
+
+		MaxCVTokens:        60000,
+		FabricationPolicy:  "warn",
+		WebhookMaxAttempts: 6,
 	}
 
 	if port := os.Getenv("PORT"); port != "" {
 		cfg.Port = port
 	}
 
+	if provider := os.Getenv("LLM_PROVIDER"); provider != "" {
+		cfg.LLMProvider = strings.ToLower(provider)
+	}
+	cfg.LLMModel = os.Getenv("LLM_MODEL")
+	cfg.LLMBaseURL = os.Getenv("LLM_BASE_URL")
+	cfg.LLMAPIKey = os.Getenv("LLM_API_KEY")
 	cfg.DeepSeekAPIKey = os.Getenv("DEEPSEEK_API_KEY")
-	if cfg.DeepSeekAPIKey == "" {
-		return nil, fmt.Errorf("DEEPSEEK_API_KEY environment variable is required")
+
+	cfg.DeepSeekBaseURL = os.Getenv("DEEPSEEK_BASE_URL")
+	cfg.OpenAIBaseURL = os.Getenv("OPENAI_BASE_URL")
+	cfg.OllamaBaseURL = os.Getenv("OLLAMA_BASE_URL")
+
+	switch cfg.LLMProvider {
+	case ProviderDeepSeek:
+		if cfg.DeepSeekBaseURL == "" {
+			cfg.DeepSeekBaseURL = cfg.LLMBaseURL
+		}
+	case ProviderOpenAI:
+		if cfg.OpenAIBaseURL == "" {
+			cfg.OpenAIBaseURL = cfg.LLMBaseURL
+		}
+	case ProviderOllama:
+		if cfg.OllamaBaseURL == "" {
+			cfg.OllamaBaseURL = cfg.LLMBaseURL
+		}
+	default:
+		return nil, fmt.Errorf("invalid LLM_PROVIDER %q: must be deepseek, openai or ollama", cfg.LLMProvider)
+	}
+
+	if err := cfg.loadModelRoutes(); err != nil {
+		return nil, err
+	}
+
+	if limitsStr := os.Getenv("MODEL_TOKEN_LIMITS"); limitsStr != "" {
+		limits, err := ParseModelTokenLimits(limitsStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MODEL_TOKEN_LIMITS value: %w", err)
+		}
+		cfg.ModelTokenLimits = limits
+	}
+
+	if maxTokensStr := os.Getenv("MAX_CV_TOKENS"); maxTokensStr != "" {
+		maxTokens, err := strconv.Atoi(maxTokensStr)
+		if err != nil || maxTokens < 0 {
+			return nil, fmt.Errorf("invalid MAX_CV_TOKENS value %q: must be a non-negative integer", maxTokensStr)
+		}
+		cfg.MaxCVTokens = maxTokens
 	}
 
 	if maxFileSizeStr := os.Getenv("MAX_FILE_SIZE"); maxFileSizeStr != "" {
@@ -38,5 +170,112 @@ func Load() (*Config, error) {
 		cfg.MaxFileSize = size
 	}
 
+	if workersStr := os.Getenv("JOB_WORKERS"); workersStr != "" {
+		workers, err := strconv.Atoi(workersStr)
+		if err != nil || workers <= 0 {
+			return nil, fmt.Errorf("invalid JOB_WORKERS value %q: must be a positive integer", workersStr)
+		}
+		cfg.JobWorkers = workers
+	}
+
+	if queueSizeStr := os.Getenv("JOB_QUEUE_SIZE"); queueSizeStr != "" {
+		size, err := strconv.Atoi(queueSizeStr)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid JOB_QUEUE_SIZE value %q: must be a positive integer", queueSizeStr)
+		}
+		cfg.JobQueueSize = size
+	}
+
+	if ttlStr := os.Getenv("JOB_TTL"); ttlStr != "" {
+		ttl, err := time.ParseDuration(ttlStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JOB_TTL value %q: %w", ttlStr, err)
+		}
+		cfg.JobTTL = ttl
+	}
+
+	if backend := os.Getenv("CACHE_BACKEND"); backend != "" {
+		cfg.CacheBackend = strings.ToLower(backend)
+		if cfg.CacheBackend != "memory" && cfg.CacheBackend != "disk" && cfg.CacheBackend != "off" {
+			return nil, fmt.Errorf("invalid CACHE_BACKEND value %q: must be memory, disk or off", backend)
+		}
+	}
+	if dir := os.Getenv("CACHE_DIR"); dir != "" {
+		cfg.CacheDir = dir
+	}
+	if entriesStr := os.Getenv("CACHE_MAX_ENTRIES"); entriesStr != "" {
+		entries, err := strconv.Atoi(entriesStr)
+		if err != nil || entries <= 0 {
+			return nil, fmt.Errorf("invalid CACHE_MAX_ENTRIES value %q: must be a positive integer", entriesStr)
+		}
+		cfg.CacheMaxEntries = entries
+	}
+
+	durations := []struct {
+		env    string
+		target *time.Duration
+	}{
+		{"FORMAT_TIMEOUT", &cfg.FormatTimeout},
+		{"ROAST_TIMEOUT", &cfg.RoastTimeout},
+		{"LETTER_TIMEOUT", &cfg.LetterTimeout},
+		{"CACHE_TTL", &cfg.CacheTTL},
+		{"SHUTDOWN_TIMEOUT", &cfg.ShutdownTimeout},
+	}
+	for _, duration := range durations {
+		value := os.Getenv(duration.env)
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid %s value %q: must be a positive duration", duration.env, value)
+		}
+		*duration.target = d
+	}
+
+	cfg.UniOfficeLicenseKey = os.Getenv("UNIOFFICE_LICENSE_KEY")
+	cfg.TemplatesDir = os.Getenv("RESUME_TEMPLATES_DIR")
+	cfg.PDFFontsDir = os.Getenv("PDF_FONTS_DIR")
+	cfg.PromptsDir = os.Getenv("PROMPTS_DIR")
+
+	if variantsStr := os.Getenv("PROMPT_VARIANTS"); variantsStr != "" {
+		variants, err := ParsePromptVariants(variantsStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PROMPT_VARIANTS value: %w", err)
+		}
+		cfg.PromptVariants = variants
+	}
+
+	if intervalStr := os.Getenv("PROMPTS_RELOAD_INTERVAL"); intervalStr != "" {
+		interval, err := time.ParseDuration(intervalStr)
+		if err != nil || interval < 0 {
+			return nil, fmt.Errorf("invalid PROMPTS_RELOAD_INTERVAL value %q: must be a non-negative duration", intervalStr)
+		}
+		cfg.PromptsReloadInterval = interval
+	}
+
+	if policy := os.Getenv("FABRICATION_POLICY"); policy != "" {
+		cfg.FabricationPolicy = strings.ToLower(policy)
+		if cfg.FabricationPolicy != "warn" && cfg.FabricationPolicy != "strip" && cfg.FabricationPolicy != "reject" {
+			return nil, fmt.Errorf("invalid FABRICATION_POLICY value %q: must be warn, strip or reject", policy)
+		}
+	}
+
+	cfg.WebhookURL = os.Getenv("BURNISHED_WEB_WEBHOOK_URL")
+	for _, secret := range []string{os.Getenv("WEBHOOK_SECRET"), os.Getenv("WEBHOOK_SECRET_PREVIOUS")} {
+		if secret != "" {
+			cfg.WebhookSecrets = append(cfg.WebhookSecrets, secret)
+		}
+	}
+	cfg.WebhookDeadLetterDir = os.Getenv("WEBHOOK_DEAD_LETTER_DIR")
+
+	if attemptsStr := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); attemptsStr != "" {
+		attempts, err := strconv.Atoi(attemptsStr)
+		if err != nil || attempts <= 0 {
+			return nil, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS value %q: must be a positive integer", attemptsStr)
+		}
+		cfg.WebhookMaxAttempts = attempts
+	}
+
 	return cfg, nil
 }
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Modes with their own model routing, matching the API's mode field.
+var RoutedModes = []string{"format", "roast", "letter"}
+
+// ModelRoute is one provider/model pair in a mode's fallback chain.
+type ModelRoute struct {
+	Provider string
+	// Model is empty for the provider's default model.
+	Model string
+	// Temperature is nil for the default temperature.
+	Temperature *float64
+}
+
+func (r ModelRoute) String() string {
+	s := r.Provider
+	if r.Model != "" {
+		s += ":" + r.Model
+	}
+	if r.Temperature != nil {
+		s += "@" + strconv.FormatFloat(*r.Temperature, 'f', -1, 64)
+	}
+	return s
+}
+
+// ParseModelRoutes parses a comma-separated chain such as
+// "deepseek:deepseek-chat@0.3,ollama:llama3.1:8b". The model follows the
+// first colon, so Ollama tags keep theirs, and a temperature may follow "@".
+func ParseModelRoutes(value string) ([]ModelRoute, error) {
+	var routes []ModelRoute
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		var route ModelRoute
+		if at := strings.LastIndex(entry, "@"); at >= 0 {
+			temperature, err := strconv.ParseFloat(entry[at+1:], 64)
+			if err != nil || temperature < 0 || temperature > 2 {
+				return nil, fmt.Errorf("invalid temperature in %q: must be between 0 and 2", entry)
+			}
+			route.Temperature = &temperature
+			entry = entry[:at]
+		}
+		provider, model, _ := strings.Cut(entry, ":")
+		route.Provider = strings.ToLower(strings.TrimSpace(provider))
+		route.Model = strings.TrimSpace(model)
+
+		switch route.Provider {
+		case ProviderDeepSeek, ProviderOpenAI, ProviderOllama:
+		default:
+			return nil, fmt.Errorf("invalid provider %q in %q: must be deepseek, openai or ollama", provider, entry)
+		}
+		routes = append(routes, route)
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no provider given")
+	}
+	return routes, nil
+}
+
+// BaseURL is the API base URL for provider, or empty for its default.
+func (c *Config) BaseURL(provider string) string {
+	switch provider {
+	case ProviderDeepSeek:
+		return c.DeepSeekBaseURL
+	case ProviderOpenAI:
+		return c.OpenAIBaseURL
+	case ProviderOllama:
+		return c.OllamaBaseURL
+	default:
+		return ""
+	}
+}
+
+// APIKey is the credential for provider; Ollama needs none.
+func (c *Config) APIKey(provider string) string {
+	switch provider {
+	case ProviderDeepSeek:
+		return c.DeepSeekAPIKey
+	case ProviderOpenAI:
+		return c.LLMAPIKey
+	default:
+		return ""
+	}
+}
+
+// loadModelRoutes reads <MODE>_MODELS for each mode, defaulting to
+// LLM_PROVIDER and LLM_MODEL, and checks every provider used has credentials.
+func (c *Config) loadModelRoutes() error {
+	c.ModelRoutes = make(map[string][]ModelRoute)
+	used := make(map[string]bool)
+	for _, mode := range RoutedModes {
+		env := strings.ToUpper(mode) + "_MODELS"
+		routes := []ModelRoute{{Provider: c.LLMProvider, Model: c.LLMModel}}
+		if value := os.Getenv(env); value != "" {
+			parsed, err := ParseModelRoutes(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s value: %w", env, err)
+			}
+			routes = parsed
+		}
+		c.ModelRoutes[mode] = routes
+		for _, route := range routes {
+			used[route.Provider] = true
+		}
+	}
+
+	if used[ProviderDeepSeek] && c.DeepSeekAPIKey == "" {
+		return fmt.Errorf("DEEPSEEK_API_KEY environment variable is required")
+	}
+	// an OpenAI-compatible server of your own may need no key, the hosted API does
+	if used[ProviderOpenAI] && c.LLMAPIKey == "" && c.OpenAIBaseURL == "" {
+		return fmt.Errorf("LLM_API_KEY environment variable is required for the hosted openai provider")
+	}
+	return nil
+}
+
+// ModelTokenLimit overrides a model's context window and, when Output is
+// set, the most it writes in one completion.
+type ModelTokenLimit struct {
+	Context int
+	Output  int
+}
+
+// ParseModelTokenLimits parses "model=context[/output]" entries separated by
+// commas, e.g. "llama3.1:8b=32768,deepseek-chat=65536/8192".
+func ParseModelTokenLimits(value string) (map[string]ModelTokenLimit, error) {
+	limits := make(map[string]ModelTokenLimit)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		eq := strings.LastIndex(entry, "=")
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid entry %q: want model=context[/output]", entry)
+		}
+		contextStr, outputStr, hasOutput := strings.Cut(entry[eq+1:], "/")
+
+		var limit ModelTokenLimit
+		var err error
+		if limit.Context, err = strconv.Atoi(contextStr); err != nil || limit.Context <= 0 {
+			return nil, fmt.Errorf("invalid context size in %q: must be a positive integer", entry)
+		}
+		if hasOutput {
+			if limit.Output, err = strconv.Atoi(outputStr); err != nil || limit.Output <= 0 || limit.Output > limit.Context {
+				return nil, fmt.Errorf("invalid output limit in %q: must be a positive integer no larger than the context", entry)
+			}
+		}
+		limits[strings.TrimSpace(entry[:eq])] = limit
+	}
+	return limits, nil
+}
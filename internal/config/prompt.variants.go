@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PromptVariant is one arm of a prompt experiment; "control" is the prompt's
+// main template and any other name the template <prompt>@<name>.tmpl.
+type PromptVariant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+// ParsePromptVariants parses experiments such as
+// "resume=control:80,b:20;cover.letter=control:50,warm:50": per prompt, the
+// variants and their relative weights.
+func ParsePromptVariants(value string) (map[string][]PromptVariant, error) {
+	experiments := make(map[string][]PromptVariant)
+	for _, experiment := range strings.Split(value, ";") {
+		experiment = strings.TrimSpace(experiment)
+		if experiment == "" {
+			continue
+		}
+		prompt, arms, ok := strings.Cut(experiment, "=")
+		prompt = strings.TrimSpace(prompt)
+		if !ok || prompt == "" {
+			return nil, fmt.Errorf("invalid experiment %q: want prompt=variant:weight,...", experiment)
+		}
+		if _, exists := experiments[prompt]; exists {
+			return nil, fmt.Errorf("prompt %s has more than one experiment", prompt)
+		}
+
+		var variants []PromptVariant
+		total := 0
+		for _, arm := range strings.Split(arms, ",") {
+			name, weightStr, ok := strings.Cut(strings.TrimSpace(arm), ":")
+			weight, err := strconv.Atoi(strings.TrimSpace(weightStr))
+			name = strings.TrimSpace(name)
+			if !ok || name == "" || strings.ContainsAny(name, `/\.@`) || err != nil || weight < 0 {
+				return nil, fmt.Errorf("invalid variant %q for prompt %s: want name:weight with a non-negative weight", arm, prompt)
+			}
+			variants = append(variants, PromptVariant{Name: name, Weight: weight})
+			total += weight
+		}
+		if total == 0 {
+			return nil, fmt.Errorf("prompt %s: variant weights add up to zero", prompt)
+		}
+		experiments[prompt] = variants
+	}
+	return experiments, nil
+}
@@ -0,0 +1,258 @@
+package documents
+
+import "unicode"
+
+// gofpdf draws glyphs in string order with no shaping, so right-to-left
+// text is shaped and reordered here before it reaches the PDF.
+
+// arabicForms lists the isolated, final, initial and medial presentation
+// forms of each Arabic letter; zero means the letter has no such form.
+var arabicForms = map[rune][4]rune{
+	0x0621: {0xFE80, 0, 0, 0},
+	0x0622: {0xFE81, 0xFE82, 0, 0},
+	0x0623: {0xFE83, 0xFE84, 0, 0},
+	0x0624: {0xFE85, 0xFE86, 0, 0},
+	0x0625: {0xFE87, 0xFE88, 0, 0},
+	0x0626: {0xFE89, 0xFE8A, 0xFE8B, 0xFE8C},
+	0x0627: {0xFE8D, 0xFE8E, 0, 0},
+	0x0628: {0xFE8F, 0xFE90, 0xFE91, 0xFE92},
+	0x0629: {0xFE93, 0xFE94, 0, 0},
+	0x062A: {0xFE95, 0xFE96, 0xFE97, 0xFE98},
+	0x062B: {0xFE99, 0xFE9A, 0xFE9B, 0xFE9C},
+	0x062C: {0xFE9D, 0xFE9E, 0xFE9F, 0xFEA0},
+	0x062D: {0xFEA1, 0xFEA2, 0xFEA3, 0xFEA4},
+	0x062E: {0xFEA5, 0xFEA6, 0xFEA7, 0xFEA8},
+	0x062F: {0xFEA9, 0xFEAA, 0, 0},
+	0x0630: {0xFEAB, 0xFEAC, 0, 0},
+	0x0631: {0xFEAD, 0xFEAE, 0, 0},
+	0x0632: {0xFEAF, 0xFEB0, 0, 0},
+	0x0633: {0xFEB1, 0xFEB2, 0xFEB3, 0xFEB4},
+	0x0634: {0xFEB5, 0xFEB6, 0xFEB7, 0xFEB8},
+	0x0635: {0xFEB9, 0xFEBA, 0xFEBB, 0xFEBC},
+	0x0636: {0xFEBD, 0xFEBE, 0xFEBF, 0xFEC0},
+	0x0637: {0xFEC1, 0xFEC2, 0xFEC3, 0xFEC4},
+	0x0638: {0xFEC5, 0xFEC6, 0xFEC7, 0xFEC8},
+	0x0639: {0xFEC9, 0xFECA, 0xFECB, 0xFECC},
+	0x063A: {0xFECD, 0xFECE, 0xFECF, 0xFED0},
+	0x0640: {0x0640, 0x0640, 0x0640, 0x0640},
+	0x0641: {0xFED1, 0xFED2, 0xFED3, 0xFED4},
+	0x0642: {0xFED5, 0xFED6, 0xFED7, 0xFED8},
+	0x0643: {0xFED9, 0xFEDA, 0xFEDB, 0xFEDC},
+	0x0644: {0xFEDD, 0xFEDE, 0xFEDF, 0xFEE0},
+	0x0645: {0xFEE1, 0xFEE2, 0xFEE3, 0xFEE4},
+	0x0646: {0xFEE5, 0xFEE6, 0xFEE7, 0xFEE8},
+	0x0647: {0xFEE9, 0xFEEA, 0xFEEB, 0xFEEC},
+	0x0648: {0xFEED, 0xFEEE, 0, 0},
+	0x0649: {0xFEEF, 0xFEF0, 0, 0},
+	0x064A: {0xFEF1, 0xFEF2, 0xFEF3, 0xFEF4},
+	// Persian and Urdu letters
+	0x067E: {0xFB56, 0xFB57, 0xFB58, 0xFB59},
+	0x0686: {0xFB7A, 0xFB7B, 0xFB7C, 0xFB7D},
+	0x0698: {0xFB8A, 0xFB8B, 0, 0},
+	0x06A9: {0xFB8E, 0xFB8F, 0xFB90, 0xFB91},
+	0x06AF: {0xFB92, 0xFB93, 0xFB94, 0xFB95},
+	0x06CC: {0xFBFC, 0xFBFD, 0xFBFE, 0xFBFF},
+}
+
+// lamAlef maps the alef following a lam to the isolated ligature; the final
+// form is the next code point.
+var lamAlef = map[rune]rune{
+	0x0622: 0xFEF5,
+	0x0623: 0xFEF7,
+	0x0625: 0xFEF9,
+	0x0627: 0xFEFB,
+}
+
+const (
+	formIsolated = iota
+	formFinal
+	formInitial
+	formMedial
+)
+
+func isArabicMark(r rune) bool {
+	return (r >= 0x064B && r <= 0x065F) || r == 0x0670
+}
+
+// joinsBefore reports whether r connects to the following letter.
+func joinsBefore(r rune) bool {
+	forms, ok := arabicForms[r]
+	return ok && forms[formInitial] != 0
+}
+
+// shapeArabic replaces Arabic letters, in logical order, with the
+// presentation form matching their position in the word.
+func shapeArabic(text string) string {
+	runes := []rune(text)
+	out := make([]rune, 0, len(runes))
+
+	// neighbour finds the nearest non-mark rune in direction step
+	neighbour := func(i, step int) rune {
+		for j := i + step; j >= 0 && j < len(runes); j += step {
+			if !isArabicMark(runes[j]) {
+				return runes[j]
+			}
+		}
+		return 0
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		forms, ok := arabicForms[r]
+		if !ok {
+			out = append(out, r)
+			continue
+		}
+		prevJoins := joinsBefore(neighbour(i, -1))
+
+		if r == 0x0644 {
+			next := neighbour(i, 1)
+			if lig, ok := lamAlef[next]; ok {
+				if prevJoins {
+					lig++
+				}
+				out = append(out, lig)
+				for i++; i < len(runes) && runes[i] != next; i++ {
+					out = append(out, runes[i]) // keep marks on the lam
+				}
+				continue
+			}
+		}
+
+		nextForms, nextIsLetter := arabicForms[neighbour(i, 1)]
+		nextJoins := nextIsLetter && nextForms[formFinal] != 0 && forms[formInitial] != 0
+
+		form := formIsolated
+		switch {
+		case prevJoins && nextJoins:
+			form = formMedial
+		case prevJoins:
+			form = formFinal
+		case nextJoins:
+			form = formInitial
+		}
+		if forms[form] == 0 {
+			form = formIsolated
+			if prevJoins && forms[formFinal] != 0 {
+				form = formFinal
+			}
+		}
+		out = append(out, forms[form])
+	}
+	return string(out)
+}
+
+func isRTL(r rune) bool {
+	return (r >= 0x0590 && r <= 0x08FF) || (r >= 0xFB1D && r <= 0xFDFF) || (r >= 0xFE70 && r <= 0xFEFF)
+}
+
+func hasRTL(text string) bool {
+	for _, r := range text {
+		if isRTL(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// rtlBase reports whether the first strongly directional character of text
+// is right-to-left, which makes the whole paragraph right-to-left.
+func rtlBase(text string) bool {
+	for _, r := range text {
+		if isRTL(r) {
+			return true
+		}
+		if unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return false
+}
+
+var mirrored = map[rune]rune{
+	'(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{', '<': '>', '>': '<',
+}
+
+// visualOrder reorders one line from logical to display order. It is a
+// simplified bidi pass: digits and Latin letters form left-to-right runs,
+// neutrals take the direction of their surroundings, and the run order is
+// reversed on right-to-left lines.
+func visualOrder(line string, rtl bool) string {
+	runes := []rune(line)
+	dirs := make([]int8, len(runes)) // 1 = RTL, -1 = LTR, 0 = neutral
+	for i, r := range runes {
+		switch {
+		case isRTL(r):
+			dirs[i] = 1
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			dirs[i] = -1
+		}
+	}
+
+	base := int8(-1)
+	if rtl {
+		base = 1
+	}
+	for i := 0; i < len(runes); i++ {
+		if dirs[i] != 0 {
+			continue
+		}
+		j := i
+		for j < len(runes) && dirs[j] == 0 {
+			j++
+		}
+		before, after := base, base
+		if i > 0 {
+			before = dirs[i-1]
+		}
+		if j < len(runes) {
+			after = dirs[j]
+		}
+		dir := base
+		if before == after {
+			dir = before
+		}
+		for k := i; k < j; k++ {
+			dirs[k] = dir
+		}
+		i = j - 1
+	}
+
+	type run struct {
+		dir   int8
+		runes []rune
+	}
+	var runs []run
+	for i, r := range runes {
+		if len(runs) == 0 || runs[len(runs)-1].dir != dirs[i] {
+			runs = append(runs, run{dir: dirs[i]})
+		}
+		runs[len(runs)-1].runes = append(runs[len(runs)-1].runes, r)
+	}
+
+	for i := range runs {
+		if runs[i].dir != 1 {
+			continue
+		}
+		rs := runs[i].runes
+		for a, b := 0, len(rs)-1; a < b; a, b = a+1, b-1 {
+			rs[a], rs[b] = rs[b], rs[a]
+		}
+		for k, r := range rs {
+			if m, ok := mirrored[r]; ok {
+				rs[k] = m
+			}
+		}
+	}
+	if rtl {
+		for a, b := 0, len(runs)-1; a < b; a, b = a+1, b-1 {
+			runs[a], runs[b] = runs[b], runs[a]
+		}
+	}
+
+	out := make([]rune, 0, len(runes))
+	for _, r := range runs {
+		out = append(out, r.runes...)
+	}
+	return string(out)
+}
@@ -0,0 +1,102 @@
+package documents
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/dtos"
+	"github.com/unidoc/unioffice/document"
+	"github.com/unidoc/unioffice/measurement"
+	"github.com/unidoc/unioffice/schema/soo/wml"
+)
+
+// CoverLetterDOCXRenderer lays out a cover letter in block business-letter
+// format as an editable Word document, styled like the resume.
+type CoverLetterDOCXRenderer struct {
+	tmpl *ResumeTemplate
+}
+
+func NewCoverLetterDOCXRenderer(tmpl *ResumeTemplate) *CoverLetterDOCXRenderer {
+	return &CoverLetterDOCXRenderer{tmpl: tmpl}
+}
+
+func (r *CoverLetterDOCXRenderer) Render(letter *dtos.CoverLetter) ([]byte, error) {
+	if letter == nil {
+		return nil, fmt.Errorf("cover letter cannot be nil")
+	}
+	tmpl := r.tmpl
+
+	doc := document.New()
+	defer doc.Close()
+
+	// letters get wider margins than a dense one-page resume
+	margin := measurement.Distance(tmpl.MarginMM+8) * measurement.Millimeter
+	section := doc.BodySection()
+	section.SetPageSizeAndOrientation(docxPageWidth, docxPageHeight, wml.ST_PageOrientationPortrait)
+	section.SetPageMargins(margin, margin, margin, margin, 0.4*measurement.Inch, 0.4*measurement.Inch, 0)
+
+	d := &resumeDOCX{
+		doc:       doc,
+		tmpl:      tmpl,
+		bodySize:  measurement.Distance(tmpl.BodySize) * measurement.Point,
+		textWidth: docxPageWidth - 2*margin,
+		linkColor: hexColor(tmpl.LinkColor),
+	}
+	d.applyStyles()
+
+	// the letterhead reuses the resume header, minus the job title
+	sender := letter.Sender
+	sender.JobTitle = ""
+	d.header(sender)
+
+	paragraphGap := measurement.Distance(tmpl.BodySize*tmpl.LineSpacing) * measurement.Point
+	if tmpl.HeadingRule {
+		rule := doc.AddParagraph()
+		rule.Borders().SetBottom(wml.ST_BorderSingle, hexColor(tmpl.AccentColor), 0.5*measurement.Point)
+	}
+
+	date := d.paragraph(letter.Date)
+	date.Properties().Spacing().SetBefore(measurement.Distance(tmpl.SectionSpacingMM) * measurement.Millimeter)
+	date.Properties().Spacing().SetAfter(paragraphGap)
+
+	if lines := recipientLines(letter.Recipient); len(lines) > 0 {
+		para := doc.AddParagraph()
+		para.Properties().Spacing().SetAfter(paragraphGap)
+		for i, line := range lines {
+			run := para.AddRun()
+			run.Properties().SetSize(d.bodySize)
+			if i > 0 {
+				run.AddBreak()
+			}
+			run.AddText(line)
+		}
+	}
+
+	d.paragraph(letter.Salutation).Properties().Spacing().SetAfter(paragraphGap)
+	for _, paragraph := range letter.Paragraphs {
+		d.paragraph(paragraph).Properties().Spacing().SetAfter(paragraphGap)
+	}
+
+	closing := d.paragraph(letter.Closing)
+	closing.Properties().SetKeepWithNext(true)
+	closing.Properties().Spacing().SetAfter(2 * paragraphGap)
+	signature := doc.AddParagraph()
+	run := signature.AddRun()
+	run.Properties().SetBold(true)
+	run.Properties().SetSize(d.bodySize)
+	run.AddText(strings.TrimSpace(letter.Signature))
+
+	buf := new(bytes.Buffer)
+	if err := doc.Save(buf); err != nil {
+		return nil, fmt.Errorf("saving cover letter DOCX: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// paragraph adds a body-text paragraph.
+func (d *resumeDOCX) paragraph(text string) document.Paragraph {
+	para := d.doc.AddParagraph()
+	d.text(para, strings.TrimSpace(text), d.bodySize)
+	return para
+}
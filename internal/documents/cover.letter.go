@@ -0,0 +1,31 @@
+package documents
+
+import (
+	"strings"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/dtos"
+)
+
+// recipientLines returns the inside address of a letter: the recipient's
+// name, title, company and address, one line each.
+func recipientLines(recipient dtos.Recipient) []string {
+	var lines []string
+	for _, part := range []string{recipient.Name, recipient.Title, recipient.Company} {
+		if part = strings.TrimSpace(part); part != "" {
+			lines = append(lines, part)
+		}
+	}
+	for _, line := range strings.Split(recipient.Address, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// CoverLetterBody is the letter as plain text without its header: the
+// salutation, body and sign-off, as they are streamed.
+func CoverLetterBody(letter *dtos.CoverLetter) string {
+	parts := append([]string{letter.Salutation}, letter.Paragraphs...)
+	return joinNonEmpty("\n\n", append(parts, letter.Closing, letter.Signature)...)
+}
@@ -0,0 +1,415 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Offsets and flags in the File Information Block (FIB) that starts the
+// WordDocument stream of a Word 97-2003 file, from [MS-DOC].
+const (
+	docIdent       = 0xA5EC
+	docMinFib      = 0x00C1 // Word 97; Word 6 and 95 files are laid out otherwise
+	docFlagsOffset = 0x000A
+	docFibRgW      = 0x0020
+
+	docEncrypted  = 0x0100
+	docTable1     = 0x0200
+	docObfuscated = 0x8000
+
+	docCompressed = 0x40000000
+)
+
+// Indexes of the stories' lengths in FibRgLw97, and of the structures the
+// extractor reads in FibRgFcLcb97.
+const (
+	lwCcpText = 3
+	lwCcpFtn  = 4
+	lwCcpHdd  = 5
+	lwCcpMcr  = 6
+	lwCcpAtn  = 7
+	lwCcpEdn  = 8
+	lwCcpTxbx = 9
+
+	fcPlcffndRef = 2
+	fcPlcfHdd    = 11
+	fcClx        = 33
+	fcPlcfendRef = 46
+)
+
+// Characters with a meaning of their own in Word text.
+const (
+	docFieldBegin     = 0x13
+	docFieldSeparator = 0x14
+	docFieldEnd       = 0x15
+	docCellMark       = 0x07
+	docNoteRef        = 0x02
+)
+
+type DOCExtractor struct{}
+
+func NewDOCExtractor() *DOCExtractor {
+	return &DOCExtractor{}
+}
+
+// ExtractText reads a Word 97-2003 CV in reading order: headers, body, text
+// boxes, notes and footers, with table rows as cells joined by " | ",
+// followed by the targets of its HYPERLINK fields.
+func (e *DOCExtractor) ExtractText(ctx context.Context, file io.Reader) (string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("reading DOC file: %w", err)
+	}
+	doc, err := readDOC(data)
+	if err != nil {
+		return "", err
+	}
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("extracting DOC text: %w", err)
+	}
+	text, links := doc.text()
+	return appendLinks(text, links), nil
+}
+
+// docFile is the text of a Word 97-2003 file, one UTF-16 code unit per
+// character position, with the FIB and table stream that describe it.
+type docFile struct {
+	fib      []byte
+	table    []byte
+	lw       []byte
+	fcLcb    []byte
+	chars    []uint16
+	stories  map[int]int // the length of each story, by FibRgLw97 index
+	starts   map[int]int // the first character position of each story
+	noteRefs map[int]string
+}
+
+// readDOC opens the compound file and reads the text through its piece
+// table, which lists where each run of characters is stored and whether as
+// Windows-1252 or UTF-16.
+func readDOC(data []byte) (*docFile, error) {
+	cfb, err := mscfb.New(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("opening DOC file: %w", err)
+	}
+	streams := make(map[string][]byte)
+	for entry, err := cfb.Next(); err == nil; entry, err = cfb.Next() {
+		switch entry.Name {
+		case "WordDocument", "0Table", "1Table":
+			stream := make([]byte, entry.Size)
+			if _, err := io.ReadFull(entry, stream); err != nil {
+				return nil, fmt.Errorf("reading DOC stream %s: %w", entry.Name, err)
+			}
+			streams[entry.Name] = stream
+		}
+	}
+
+	fib := streams["WordDocument"]
+	if len(fib) < docFibRgW+2 || binary.LittleEndian.Uint16(fib) != docIdent {
+		return nil, fmt.Errorf("parsing DOC: not a Word document")
+	}
+	if nFib := binary.LittleEndian.Uint16(fib[2:]); nFib < docMinFib {
+		return nil, fmt.Errorf("%w: Word 6 and 95 files are not supported", ErrUnsupportedFormat)
+	}
+	flags := binary.LittleEndian.Uint16(fib[docFlagsOffset:])
+	if flags&(docEncrypted|docObfuscated) != 0 {
+		return nil, fmt.Errorf("parsing DOC: %w", ErrEncryptedFile)
+	}
+	tableName := "0Table"
+	if flags&docTable1 != 0 {
+		tableName = "1Table"
+	}
+
+	d := &docFile{fib: fib, table: streams[tableName]}
+	if err := d.readFib(); err != nil {
+		return nil, fmt.Errorf("parsing DOC: %w", err)
+	}
+	if err := d.readPieces(); err != nil {
+		return nil, fmt.Errorf("parsing DOC: %w", err)
+	}
+	return d, nil
+}
+
+// readFib finds the variable-length parts of the FIB and the story lengths.
+func (d *docFile) readFib() error {
+	off := docFibRgW
+	csw := int(binary.LittleEndian.Uint16(d.fib[off:]))
+	off += 2 + csw*2
+	if off+2 > len(d.fib) {
+		return fmt.Errorf("FIB is truncated")
+	}
+	cslw := int(binary.LittleEndian.Uint16(d.fib[off:]))
+	d.lw = d.fib[off+2 : min(off+2+cslw*4, len(d.fib))]
+	off += 2 + cslw*4
+	if off+2 > len(d.fib) || len(d.lw) <= lwCcpTxbx*4 {
+		return fmt.Errorf("FIB is truncated")
+	}
+	cbRgFcLcb := int(binary.LittleEndian.Uint16(d.fib[off:]))
+	d.fcLcb = d.fib[off+2 : min(off+2+cbRgFcLcb*8, len(d.fib))]
+
+	// the stories follow each other: body, footnotes, headers, macros,
+	// comments, endnotes and text boxes
+	d.stories = make(map[int]int)
+	d.starts = make(map[int]int)
+	cp := 0
+	for i := lwCcpText; i <= lwCcpTxbx; i++ {
+		n := int(int32(binary.LittleEndian.Uint32(d.lw[i*4:])))
+		if n < 0 {
+			return fmt.Errorf("story %d has a negative length", i)
+		}
+		d.starts[i], d.stories[i] = cp, n
+		cp += n
+	}
+	return nil
+}
+
+// plc returns the structure at index i of FibRgFcLcb97 in the table stream,
+// or nil when the file has none.
+func (d *docFile) plc(i int) []byte {
+	if len(d.fcLcb) < (i+1)*8 {
+		return nil
+	}
+	fc := binary.LittleEndian.Uint32(d.fcLcb[i*8:])
+	lcb := binary.LittleEndian.Uint32(d.fcLcb[i*8+4:])
+	if lcb == 0 || uint64(fc)+uint64(lcb) > uint64(len(d.table)) {
+		return nil
+	}
+	return d.table[fc : fc+lcb]
+}
+
+// readPieces reads the text of every story through the piece table in the
+// Clx structure.
+func (d *docFile) readPieces() error {
+	clx := d.plc(fcClx)
+	if clx == nil {
+		return fmt.Errorf("missing piece table")
+	}
+	// skip the Prc entries of formatting changes before the Pcdt
+	for len(clx) > 3 && clx[0] == 0x01 {
+		size := int(binary.LittleEndian.Uint16(clx[1:]))
+		if 3+size > len(clx) {
+			return fmt.Errorf("truncated piece table")
+		}
+		clx = clx[3+size:]
+	}
+	if len(clx) < 5 || clx[0] != 0x02 {
+		return fmt.Errorf("missing piece table")
+	}
+	size := int(binary.LittleEndian.Uint32(clx[1:]))
+	plcPcd := clx[5:]
+	if size > len(plcPcd) || (size-4)%12 != 0 {
+		return fmt.Errorf("truncated piece table")
+	}
+	plcPcd = plcPcd[:size]
+
+	n := (size - 4) / 12
+	for i := 0; i < n; i++ {
+		start := int(binary.LittleEndian.Uint32(plcPcd[i*4:]))
+		end := int(binary.LittleEndian.Uint32(plcPcd[(i+1)*4:]))
+		pcd := plcPcd[(n+1)*4+i*8:]
+		fc := binary.LittleEndian.Uint32(pcd[2:])
+		count := end - start
+		if count <= 0 {
+			continue
+		}
+
+		if fc&docCompressed != 0 {
+			off := int(fc&^docCompressed) / 2
+			if off+count > len(d.fib) {
+				return fmt.Errorf("piece %d is outside the document", i)
+			}
+			for _, b := range d.fib[off : off+count] {
+				d.chars = append(d.chars, uint16(charmap.Windows1252.DecodeByte(b)))
+			}
+			continue
+		}
+		off := int(fc)
+		if off+count*2 > len(d.fib) {
+			return fmt.Errorf("piece %d is outside the document", i)
+		}
+		for j := 0; j < count; j++ {
+			d.chars = append(d.chars, binary.LittleEndian.Uint16(d.fib[off+j*2:]))
+		}
+	}
+	return nil
+}
+
+// story returns the characters of a story.
+func (d *docFile) story(i int) []uint16 {
+	start, end := d.starts[i], d.starts[i]+d.stories[i]
+	if end > len(d.chars) || start >= end {
+		return nil
+	}
+	return d.chars[start:end]
+}
+
+// text reads the stories in the order DOCX extraction uses.
+func (d *docFile) text() (string, []link) {
+	d.readNoteRefs()
+
+	headers, footers := d.headersFooters()
+	var blocks [][]string
+	var links []link
+	for _, header := range headers {
+		lines, found := d.lines(header, d.starts[lwCcpHdd], false, "")
+		blocks, links = append(blocks, lines), append(links, found...)
+	}
+	for _, story := range []struct {
+		index  int
+		notes  bool
+		prefix string
+	}{{lwCcpText, false, ""}, {lwCcpTxbx, false, ""}, {lwCcpFtn, true, ""}, {lwCcpEdn, true, "e"}} {
+		lines, found := d.lines(d.story(story.index), d.starts[story.index], story.notes, story.prefix)
+		blocks, links = append(blocks, lines), append(links, found...)
+	}
+	for _, footer := range footers {
+		lines, found := d.lines(footer, d.starts[lwCcpHdd], false, "")
+		blocks, links = append(blocks, lines), append(links, found...)
+	}
+	return joinBlocks(blocks), links
+}
+
+// readNoteRefs numbers the footnote and endnote references in the body by
+// their character positions.
+func (d *docFile) readNoteRefs() {
+	d.noteRefs = make(map[int]string)
+	for _, refs := range []struct {
+		plc    int
+		prefix string
+	}{{fcPlcffndRef, ""}, {fcPlcfendRef, "e"}} {
+		plc := d.plc(refs.plc)
+		// n+1 positions, then two bytes of data per reference
+		n := (len(plc) - 4) / 6
+		for i := 0; i < n; i++ {
+			cp := int(binary.LittleEndian.Uint32(plc[i*4:]))
+			d.noteRefs[cp] = fmt.Sprintf("[%s%d]", refs.prefix, i+1)
+		}
+	}
+}
+
+// headersFooters splits the header story into its headers and footers. The
+// first six stories are note separators; then each section has an even
+// header, odd header, even footer, odd footer, first header and first
+// footer, empty when it repeats the previous section's.
+func (d *docFile) headersFooters() (headers, footers [][]uint16) {
+	plc := d.plc(fcPlcfHdd)
+	hdd := d.story(lwCcpHdd)
+	count := len(plc) / 4
+	for i := 6; i+1 < count; i++ {
+		start := int(binary.LittleEndian.Uint32(plc[i*4:]))
+		end := int(binary.LittleEndian.Uint32(plc[(i+1)*4:]))
+		if start >= end || end > len(hdd) {
+			continue
+		}
+		switch (i - 6) % 6 {
+		case 0, 1, 4:
+			headers = append(headers, hdd[start:end])
+		default:
+			footers = append(footers, hdd[start:end])
+		}
+	}
+	return headers, footers
+}
+
+// docField is a field being read: its code and, after the separator, its
+// result.
+type docField struct {
+	code      strings.Builder
+	inResult  bool
+	startLine int
+	startText string
+}
+
+// lines turns a story into lines. start is the story's first character
+// position, used to find note references in the body; the notes of a note
+// story are marked with prefix and numbered in order.
+func (d *docFile) lines(chars []uint16, start int, notes bool, prefix string) ([]string, []link) {
+	var lines, cells []string
+	var line strings.Builder
+	var fields []*docField
+	var links []link
+	count := 0
+
+	breakLine := func() {
+		lines = append(lines, strings.Join(strings.Fields(line.String()), " "))
+		line.Reset()
+	}
+	// hidden reports whether text is in a field's code, which is not shown
+	hidden := func() bool {
+		return len(fields) > 0 && !fields[len(fields)-1].inResult
+	}
+
+	for i := 0; i < len(chars); i++ {
+		c := rune(chars[i])
+		switch c {
+		case docFieldBegin:
+			fields = append(fields, &docField{})
+			continue
+		case docFieldSeparator:
+			if n := len(fields); n > 0 {
+				fields[n-1].inResult = true
+				fields[n-1].startLine, fields[n-1].startText = len(lines), line.String()
+			}
+			continue
+		case docFieldEnd:
+			if n := len(fields); n > 0 {
+				field := fields[n-1]
+				fields = fields[:n-1]
+				if m := hyperlinkField.FindStringSubmatch(field.code.String()); m != nil && field.inResult {
+					links = append(links, link{text: textSince(lines, line.String(), field.startLine, field.startText), url: m[1]})
+				}
+			}
+			continue
+		}
+		if hidden() {
+			fields[len(fields)-1].code.WriteRune(c)
+			continue
+		}
+
+		switch {
+		case c == 0x0D || c == 0x0B || c == 0x0C:
+			breakLine()
+		case c == docCellMark:
+			// every cell ends with a mark, and the row with one more
+			if i == 0 || chars[i-1] != docCellMark || line.Len() > 0 {
+				cells = append(cells, strings.Join(strings.Fields(line.String()), " "))
+				line.Reset()
+				continue
+			}
+			var row []string
+			for _, cell := range cells {
+				if cell != "" {
+					row = append(row, cell)
+				}
+			}
+			cells = nil
+			lines = append(lines, strings.Join(row, " | "))
+		case c == docNoteRef && notes:
+			count++
+			line.WriteString(fmt.Sprintf("[%s%d] ", prefix, count))
+		case c == docNoteRef:
+			line.WriteString(d.noteRefs[start+i])
+		case c == 0x09 || c == 0xA0:
+			line.WriteString(" ")
+		case c == 0x1E:
+			line.WriteString("-")
+		case c < 0x20 || c == 0x1F:
+			// pictures, drawn objects, comment marks and optional hyphens
+		case utf16.IsSurrogate(c) && i+1 < len(chars):
+			line.WriteRune(utf16.DecodeRune(c, rune(chars[i+1])))
+			i++
+		default:
+			line.WriteRune(c)
+		}
+	}
+	breakLine()
+	return trimBlankLines(lines), links
+}
@@ -2,21 +2,34 @@ package documents
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"strings"
 
+	"github.com/unidoc/unioffice/common/license"
 	"github.com/unidoc/unioffice/document"
 )
 
+// SetLicenseKey activates unioffice, which refuses to read or write DOCX
+// files without a metered license key.
+func SetLicenseKey(key string) error {
+	if err := license.SetMeteredKey(key); err != nil {
+		return fmt.Errorf("setting unioffice license: %w", err)
+	}
+	return nil
+}
+
 type DOCXProcessor struct {}
 
 func NewDOCXProcessor() *DOCXProcessor {
 	return &DOCXProcessor{}
 }
 
-// ExtractText extracts plain text from a DOCX file.
-func (p *DOCXProcessor) ExtractText(file io.Reader) (string, error) {
+// ExtractText extracts the text of a DOCX file in reading order: headers,
+// body, notes and footers, with tables, text boxes and list numbers, followed
+// by the targets of its web links.
+func (p *DOCXProcessor) ExtractText(ctx context.Context, file io.Reader) (string, error) {
 	fileBytes, err := io.ReadAll(file)
 	if err != nil {
 		return "", fmt.Errorf("reading DOCX file: %w", err)
@@ -28,16 +41,12 @@ func (p *DOCXProcessor) ExtractText(file io.Reader) (string, error) {
 		return "", fmt.Errorf("parsing DOCX: %w", err)
 	}
 	defer doc.Close()
-
-	var text strings.Builder
-	for _, para := range doc.Paragraphs() {
-		for _, run := range para.Runs() {
-			text.WriteString(run.Text())
-		}
-		text.WriteString("\n")
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("extracting DOCX text: %w", err)
 	}
 
-	return text.String(), nil
+	text, links := extractDOCXText(doc, readHeaderFooterRels(fileBytes))
+	return appendLinks(text, links), nil
 }
 
 // CreateFormattedDocument creates a new DOCX from content.
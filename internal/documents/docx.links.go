@@ -0,0 +1,124 @@
+package documents
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// hyperlinkField matches the address in a HYPERLINK field code, e.g.
+// HYPERLINK "https://github.com/ada" \o "GitHub".
+var hyperlinkField = regexp.MustCompile(`(?i)\bHYPERLINK\s+"([^"]+)"`)
+
+// headerFooterRels are the relationships of a DOCX's headers and footers, in
+// the order unioffice loads the parts: the order the document's own
+// relationships list them. A hyperlink in a header names its address by one
+// of these.
+type headerFooterRels struct {
+	headers, footers []map[string]string
+}
+
+func (r headerFooterRels) header(i int) func(id string) string {
+	return lookupRel(r.headers, i)
+}
+
+func (r headerFooterRels) footer(i int) func(id string) string {
+	return lookupRel(r.footers, i)
+}
+
+func lookupRel(parts []map[string]string, i int) func(id string) string {
+	return func(id string) string {
+		if i >= len(parts) {
+			return ""
+		}
+		return parts[i][id]
+	}
+}
+
+const (
+	relTypeOfficeDocument = "/officeDocument"
+	relTypeHeader         = "/header"
+	relTypeFooter         = "/footer"
+)
+
+type xmlRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Type   string `xml:"Type,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// readHeaderFooterRels reads the relationships of the headers and footers
+// from the DOCX package. A part whose relationships cannot be read has
+// none, so only its links go unresolved.
+func readHeaderFooterRels(data []byte) headerFooterRels {
+	var rels headerFooterRels
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return rels
+	}
+
+	documentPart := ""
+	for _, rel := range readRels(archive, "").Relationships {
+		if strings.HasSuffix(rel.Type, relTypeOfficeDocument) {
+			documentPart = partName("", rel.Target)
+		}
+	}
+	if documentPart == "" {
+		return rels
+	}
+
+	for _, rel := range readRels(archive, documentPart).Relationships {
+		part := partName(path.Dir(documentPart), rel.Target)
+		switch {
+		case strings.HasSuffix(rel.Type, relTypeHeader):
+			rels.headers = append(rels.headers, relTargets(readRels(archive, part)))
+		case strings.HasSuffix(rel.Type, relTypeFooter):
+			rels.footers = append(rels.footers, relTargets(readRels(archive, part)))
+		}
+	}
+	return rels
+}
+
+// readRels reads the relationships of a part, or of the package when part
+// is empty.
+func readRels(archive *zip.Reader, part string) xmlRelationships {
+	var rels xmlRelationships
+	name := "_rels/.rels"
+	if part != "" {
+		name = path.Join(path.Dir(part), "_rels", path.Base(part)+".rels")
+	}
+	file, err := archive.Open(name)
+	if err != nil {
+		return rels
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return rels
+	}
+	xml.Unmarshal(data, &rels)
+	return rels
+}
+
+func relTargets(rels xmlRelationships) map[string]string {
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		targets[rel.ID] = rel.Target
+	}
+	return targets
+}
+
+// partName resolves a relationship target against the directory of the
+// part it belongs to; targets starting with / are from the package root.
+func partName(dir, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Join(dir, target)
+}
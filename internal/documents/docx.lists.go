@@ -0,0 +1,169 @@
+package documents
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unioffice/document"
+	"github.com/unidoc/unioffice/schema/soo/wml"
+)
+
+// maxListLevels is how deep Word lists nest.
+const maxListLevels = 9
+
+// listNumbers counts the items of each list in a document as it is read, so
+// each item can be written with the bullet or number Word shows for it.
+type listNumbers struct {
+	doc *document.Document
+	// counts are the items seen at each level of each list, by numId
+	counts map[int64][]int
+}
+
+func newListNumbers(doc *document.Document) *listNumbers {
+	return &listNumbers{doc: doc, counts: make(map[int64][]int)}
+}
+
+// label counts a paragraph as a list item and returns its bullet or number,
+// e.g. "•", "2." or "1.a)", or "" when it is not in a list. The list may be
+// set on the paragraph or on its style.
+func (l *listNumbers) label(ppr *wml.CT_PPr) string {
+	numID, level, ok := l.numbering(ppr)
+	if !ok {
+		return ""
+	}
+	lvl := l.level(numID, level)
+	if lvl == nil {
+		return ""
+	}
+
+	counts, ok := l.counts[numID]
+	if !ok {
+		counts = make([]int, maxListLevels)
+		l.counts[numID] = counts
+	}
+	counts[level]++
+	// a new item restarts the numbering of the levels below it
+	for deeper := level + 1; deeper < maxListLevels; deeper++ {
+		counts[deeper] = 0
+	}
+
+	if numberFormat(lvl) == wml.ST_NumberFormatBullet {
+		return "•"
+	}
+	if lvl.LvlText == nil || lvl.LvlText.ValAttr == nil {
+		return ""
+	}
+	// the level text refers to the number of level n as %n
+	text := *lvl.LvlText.ValAttr
+	for n := 1; n <= level+1; n++ {
+		placeholder := "%" + strconv.Itoa(n)
+		if !strings.Contains(text, placeholder) {
+			continue
+		}
+		text = strings.ReplaceAll(text, placeholder, formatListNumber(l.level(numID, n-1), max(counts[n-1], 1)))
+	}
+	return strings.TrimSpace(text)
+}
+
+// numbering finds the list and level of a paragraph, from its own
+// properties or else from its style and the styles that is based on.
+func (l *listNumbers) numbering(ppr *wml.CT_PPr) (numID int64, level int, ok bool) {
+	if ppr == nil {
+		return 0, 0, false
+	}
+	numPr := ppr.NumPr
+	if numPr == nil && ppr.PStyle != nil {
+		id := ppr.PStyle.ValAttr
+		for depth := 0; numPr == nil && id != "" && depth < 10; depth++ {
+			style, found := l.doc.Styles.SearchStyleById(id)
+			if !found {
+				break
+			}
+			if x := style.X(); x.PPr != nil && x.PPr.NumPr != nil {
+				numPr = x.PPr.NumPr
+			}
+			id = ""
+			if x := style.X(); x.BasedOn != nil {
+				id = x.BasedOn.ValAttr
+			}
+		}
+	}
+	// numId 0 takes a paragraph out of the list its style puts it in
+	if numPr == nil || numPr.NumId == nil || numPr.NumId.ValAttr == 0 {
+		return 0, 0, false
+	}
+	if numPr.Ilvl != nil {
+		level = int(numPr.Ilvl.ValAttr)
+	}
+	if level < 0 || level >= maxListLevels {
+		return 0, 0, false
+	}
+	return numPr.NumId.ValAttr, level, true
+}
+
+func (l *listNumbers) level(numID int64, level int) *wml.CT_Lvl {
+	return l.doc.GetNumberingLevelByIds(numID, int64(level)).X()
+}
+
+func numberFormat(lvl *wml.CT_Lvl) wml.ST_NumberFormat {
+	if lvl == nil || lvl.NumFmt == nil {
+		return wml.ST_NumberFormatDecimal
+	}
+	return lvl.NumFmt.ValAttr
+}
+
+// formatListNumber writes the count-th item of a level in the level's
+// number format, counting from its start value.
+func formatListNumber(lvl *wml.CT_Lvl, count int) string {
+	n := count
+	if lvl != nil && lvl.Start != nil {
+		n += int(lvl.Start.ValAttr) - 1
+	}
+	switch numberFormat(lvl) {
+	case wml.ST_NumberFormatNone, wml.ST_NumberFormatBullet:
+		return ""
+	case wml.ST_NumberFormatDecimalZero:
+		return fmt.Sprintf("%02d", n)
+	case wml.ST_NumberFormatLowerLetter:
+		return strings.ToLower(letters(n))
+	case wml.ST_NumberFormatUpperLetter:
+		return letters(n)
+	case wml.ST_NumberFormatLowerRoman:
+		return strings.ToLower(roman(n))
+	case wml.ST_NumberFormatUpperRoman:
+		return roman(n)
+	default:
+		return strconv.Itoa(n)
+	}
+}
+
+// letters numbers as Word does: A to Z, then AA to ZZ, and so on.
+func letters(n int) string {
+	if n < 1 {
+		return strconv.Itoa(n)
+	}
+	letter := string(rune('A' + (n-1)%26))
+	return strings.Repeat(letter, (n-1)/26+1)
+}
+
+func roman(n int) string {
+	if n < 1 || n >= 4000 {
+		return strconv.Itoa(n)
+	}
+	numerals := []struct {
+		value  int
+		symbol string
+	}{
+		{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"},
+		{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+	}
+	var text strings.Builder
+	for _, numeral := range numerals {
+		for n >= numeral.value {
+			text.WriteString(numeral.symbol)
+			n -= numeral.value
+		}
+	}
+	return text.String()
+}
@@ -0,0 +1,108 @@
+package documents
+
+import (
+	"testing"
+
+	"github.com/unidoc/unioffice/document"
+	"github.com/unidoc/unioffice/schema/soo/wml"
+)
+
+func TestFormatListNumber(t *testing.T) {
+	level := func(format wml.ST_NumberFormat, start int64) *wml.CT_Lvl {
+		lvl := &wml.CT_Lvl{NumFmt: &wml.CT_NumFmt{ValAttr: format}}
+		if start != 0 {
+			lvl.Start = &wml.CT_DecimalNumber{ValAttr: start}
+		}
+		return lvl
+	}
+
+	tests := []struct {
+		name  string
+		lvl   *wml.CT_Lvl
+		count int
+		want  string
+	}{
+		{"no level", nil, 3, "3"},
+		{"decimal", level(wml.ST_NumberFormatDecimal, 0), 2, "2"},
+		{"decimal from start", level(wml.ST_NumberFormatDecimal, 5), 2, "6"},
+		{"zero padded", level(wml.ST_NumberFormatDecimalZero, 0), 7, "07"},
+		{"lower letter", level(wml.ST_NumberFormatLowerLetter, 0), 2, "b"},
+		{"upper letter past Z", level(wml.ST_NumberFormatUpperLetter, 0), 28, "BB"},
+		{"lower roman", level(wml.ST_NumberFormatLowerRoman, 0), 4, "iv"},
+		{"upper roman", level(wml.ST_NumberFormatUpperRoman, 0), 1994, "MCMXCIV"},
+		{"roman out of range", level(wml.ST_NumberFormatUpperRoman, 0), 4000, "4000"},
+		{"letter from zero start", level(wml.ST_NumberFormatLowerLetter, -1), 1, "-1"},
+		{"bullet", level(wml.ST_NumberFormatBullet, 0), 1, ""},
+		{"none", level(wml.ST_NumberFormatNone, 0), 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatListNumber(tt.lvl, tt.count); got != tt.want {
+				t.Errorf("formatListNumber = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListNumbersLabel(t *testing.T) {
+	doc := document.New()
+	defer doc.Close()
+
+	outline := doc.Numbering.AddDefinition()
+	top := outline.AddLevel()
+	top.SetFormat(wml.ST_NumberFormatDecimal)
+	top.SetText("%1.")
+	sub := outline.AddLevel()
+	sub.SetFormat(wml.ST_NumberFormatLowerLetter)
+	sub.SetText("%1.%2)")
+
+	bullets := doc.Numbering.AddDefinition()
+	dot := bullets.AddLevel()
+	dot.SetFormat(wml.ST_NumberFormatBullet)
+	dot.SetText("")
+
+	item := func(def document.NumberingDefinition, level int) *wml.CT_PPr {
+		p := doc.AddParagraph()
+		p.SetNumberingDefinition(def)
+		p.SetNumberingLevel(level)
+		return p.X().PPr
+	}
+
+	// a style can put its paragraphs in a list, and a paragraph can take
+	// itself out of it again with numId 0
+	styled := doc.Styles.AddStyle("ListItem", wml.ST_StyleTypeParagraph, false)
+	styled.X().PPr = &wml.CT_PPrGeneral{NumPr: item(outline, 0).NumPr}
+	derived := doc.Styles.AddStyle("ListItemBold", wml.ST_StyleTypeParagraph, false)
+	derived.SetBasedOn("ListItem")
+	withStyle := func(id string) *wml.CT_PPr {
+		return &wml.CT_PPr{PStyle: &wml.CT_String{ValAttr: id}}
+	}
+	outOfList := withStyle("ListItem")
+	outOfList.NumPr = &wml.CT_NumPr{NumId: &wml.CT_DecimalNumber{ValAttr: 0}}
+
+	paragraphs := []struct {
+		ppr  *wml.CT_PPr
+		want string
+	}{
+		{item(outline, 0), "1."},
+		{item(outline, 1), "1.a)"},
+		{item(outline, 1), "1.b)"},
+		{item(bullets, 0), "•"},
+		{item(outline, 0), "2."},
+		// the new top-level item restarted the second level
+		{item(outline, 1), "2.a)"},
+		{nil, ""},
+		{&wml.CT_PPr{}, ""},
+		{withStyle("Normal"), ""},
+		{outOfList, ""},
+		{withStyle("ListItem"), "3."},
+		{withStyle("ListItemBold"), "4."},
+	}
+
+	l := newListNumbers(doc)
+	for i, p := range paragraphs {
+		if got := l.label(p.ppr); got != p.want {
+			t.Errorf("paragraph %d: label = %q, want %q", i, got, p.want)
+		}
+	}
+}
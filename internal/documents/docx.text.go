@@ -0,0 +1,499 @@
+package documents
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/unidoc/unioffice/document"
+	"github.com/unidoc/unioffice/schema/soo/dml"
+	"github.com/unidoc/unioffice/schema/soo/wml"
+	"github.com/unidoc/unioffice/schema/urn/schemas_microsoft_com/vml"
+)
+
+// docxText is the text of a Word document in reading order: its headers,
+// the body, the notes it refers to and its footers. In the body, tables are
+// read row by row, text boxes after the paragraph they are anchored to and
+// list items with their bullet or number.
+type docxText struct {
+	// resolve looks up a relationship of the part being read
+	resolve func(id string) string
+
+	lines []string
+	links []link
+
+	// the paragraph being read, the text boxes anchored in it and the
+	// fields open in it
+	text   *strings.Builder
+	boxes  [][]*wml.EG_ContentBlockContent
+	fields []*docxField
+
+	lists     *listNumbers
+	footnotes []int64
+	endnotes  []int64
+}
+
+// docxField is a complex field: its code, spread over instrText runs, and
+// where its result starts in the paragraph's text.
+type docxField struct {
+	code   strings.Builder
+	result int
+	shown  bool
+}
+
+// extractDOCXText reads the text of doc and the links in it. parts resolves
+// the relationships of its headers and footers, which unioffice reads but
+// does not expose.
+func extractDOCXText(doc *document.Document, parts headerFooterRels) (string, []link) {
+	t := &docxText{lists: newListNumbers(doc)}
+
+	var blocks [][]string
+	for i, header := range doc.Headers() {
+		t.resolve = parts.header(i)
+		blocks = append(blocks, t.capture(func() { t.blocks(header.X().EG_ContentBlockContent) }))
+	}
+	t.resolve = doc.GetTargetByRelId
+	if body := doc.X().Body; body != nil {
+		blocks = append(blocks, t.capture(func() { t.blockElts(body.EG_BlockLevelElts) }))
+	}
+	// notes have relationships of their own, which unioffice does not expose
+	t.resolve = nil
+	// unioffice panics listing notes a document has no part for
+	var footnotes, endnotes []*wml.CT_FtnEdn
+	if doc.HasFootnotes() {
+		for _, note := range doc.Footnotes() {
+			footnotes = append(footnotes, note.X())
+		}
+	}
+	if doc.HasEndnotes() {
+		for _, note := range doc.Endnotes() {
+			endnotes = append(endnotes, note.X())
+		}
+	}
+	blocks = append(blocks, t.notes(t.footnotes, footnotes, ""), t.notes(t.endnotes, endnotes, "e"))
+	for i, footer := range doc.Footers() {
+		t.resolve = parts.footer(i)
+		blocks = append(blocks, t.capture(func() { t.blocks(footer.X().EG_ContentBlockContent) }))
+	}
+
+	return joinBlocks(blocks), t.links
+}
+
+// joinBlocks writes out the blocks a blank line apart, leaving out empty
+// blocks, blocks repeating an earlier one, such as a first-page header, and
+// runs of blank lines.
+func joinBlocks(blocks [][]string) string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, block := range blocks {
+		key := strings.Join(block, "\n")
+		if strings.TrimSpace(key) == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if len(out) > 0 {
+			out = append(out, "")
+		}
+		out = append(out, block...)
+	}
+
+	var text strings.Builder
+	blank := true
+	for _, line := range out {
+		if line == "" {
+			if !blank {
+				text.WriteString("\n")
+			}
+			blank = true
+			continue
+		}
+		text.WriteString(line + "\n")
+		blank = false
+	}
+	return text.String()
+}
+
+// capture returns the lines read by fn instead of adding them to t.lines.
+func (t *docxText) capture(fn func()) []string {
+	saved := t.lines
+	t.lines = nil
+	fn()
+	lines := t.lines
+	t.lines = saved
+	return lines
+}
+
+func (t *docxText) blockElts(elts []*wml.EG_BlockLevelElts) {
+	for _, elt := range elts {
+		t.blocks(elt.EG_ContentBlockContent)
+	}
+}
+
+func (t *docxText) blocks(contents []*wml.EG_ContentBlockContent) {
+	for _, content := range contents {
+		if content.CustomXml != nil {
+			t.blocks(content.CustomXml.EG_ContentBlockContent)
+		}
+		// content controls, which templates wrap around contact details
+		for sdt := content.Sdt; sdt != nil && sdt.SdtContent != nil; sdt = sdt.SdtContent.Sdt {
+			t.blocks([]*wml.EG_ContentBlockContent{{
+				CustomXml: sdt.SdtContent.CustomXml,
+				P:         sdt.SdtContent.P,
+				Tbl:       sdt.SdtContent.Tbl,
+			}})
+		}
+		for _, p := range content.P {
+			t.paragraph(p)
+		}
+		for _, tbl := range content.Tbl {
+			t.table(tbl)
+		}
+	}
+}
+
+// paragraph adds a paragraph's text as a line, or several where it has
+// line breaks, then the text boxes anchored in it.
+func (t *docxText) paragraph(p *wml.CT_P) {
+	outerText, outerBoxes, outerFields := t.text, t.boxes, t.fields
+	t.text, t.boxes, t.fields = &strings.Builder{}, nil, nil
+
+	label := t.lists.label(p.PPr)
+	t.pContent(p.EG_PContent)
+	for len(t.fields) > 0 {
+		t.endField()
+	}
+	text, boxes := t.text.String(), t.boxes
+	t.text, t.boxes, t.fields = outerText, outerBoxes, outerFields
+
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if i == 0 && label != "" && line != "" {
+			line = label + " " + line
+		}
+		t.lines = append(t.lines, line)
+	}
+	for _, box := range boxes {
+		t.lines = append(t.lines, "")
+		t.blocks(box)
+		t.lines = append(t.lines, "")
+	}
+}
+
+func (t *docxText) pContent(contents []*wml.EG_PContent) {
+	for _, content := range contents {
+		t.inline(content.FldSimple, content.Hyperlink, content.EG_ContentRunContent)
+	}
+}
+
+// inline reads the simple fields, hyperlink and runs of a paragraph or of
+// an element wrapping part of one.
+func (t *docxText) inline(fields []*wml.CT_SimpleField, h *wml.CT_Hyperlink, runs []*wml.EG_ContentRunContent) {
+	for _, field := range fields {
+		start := t.text.Len()
+		t.pContent(field.EG_PContent)
+		t.fieldLink(field.InstrAttr, t.text.String()[start:])
+	}
+	if h != nil {
+		start := t.text.Len()
+		t.inline(h.FldSimple, h.Hyperlink, h.EG_ContentRunContent)
+		if h.IdAttr != nil && t.resolve != nil {
+			if address := t.resolve(*h.IdAttr); address != "" {
+				t.links = append(t.links, link{text: t.text.String()[start:], url: address})
+			}
+		}
+	}
+	t.runContent(runs)
+}
+
+func (t *docxText) runContent(contents []*wml.EG_ContentRunContent) {
+	for _, content := range contents {
+		switch {
+		case content.CustomXml != nil:
+			t.pContent(content.CustomXml.EG_PContent)
+		case content.SmartTag != nil:
+			t.pContent(content.SmartTag.EG_PContent)
+		case content.Sdt != nil && content.Sdt.SdtContent != nil:
+			c := content.Sdt.SdtContent
+			t.inline(c.FldSimple, c.Hyperlink, c.EG_ContentRunContent)
+		case content.Dir != nil:
+			t.inline(content.Dir.FldSimple, content.Dir.Hyperlink, content.Dir.EG_ContentRunContent)
+		case content.Bdo != nil:
+			t.inline(content.Bdo.FldSimple, content.Bdo.Hyperlink, content.Bdo.EG_ContentRunContent)
+		case content.R != nil:
+			t.run(content.R)
+		}
+	}
+}
+
+func (t *docxText) run(r *wml.CT_R) {
+	for _, inner := range r.EG_RunInnerContent {
+		switch {
+		case inner.T != nil:
+			t.write(inner.T.Content)
+		case inner.Tab != nil, inner.Ptab != nil:
+			t.write(" ")
+		case inner.Br != nil, inner.Cr != nil:
+			t.write("\n")
+		case inner.NoBreakHyphen != nil:
+			t.write("-")
+		case inner.FootnoteReference != nil:
+			t.write(noteMark(&t.footnotes, inner.FootnoteReference.IdAttr, ""))
+		case inner.EndnoteReference != nil:
+			t.write(noteMark(&t.endnotes, inner.EndnoteReference.IdAttr, "e"))
+		case inner.FldChar != nil:
+			t.fieldChar(inner.FldChar)
+		case inner.InstrText != nil:
+			if n := len(t.fields); n > 0 {
+				t.fields[n-1].code.WriteString(inner.InstrText.Content)
+			}
+		case inner.Drawing != nil:
+			t.drawing(inner.Drawing)
+		case inner.Pict != nil:
+			t.picture(inner.Pict)
+		}
+	}
+	// Word writes text boxes as alternate content, a DrawingML shape with
+	// a VML fallback; the shape is read and the fallback left alone
+	for _, extra := range r.Extra {
+		if alt, ok := extra.(*wml.AlternateContentRun); ok && alt.Choice != nil {
+			if alt.Choice.Drawing != nil {
+				t.drawing(alt.Choice.Drawing)
+			}
+			if alt.Choice.Pict != nil {
+				t.picture(alt.Choice.Pict)
+			}
+		}
+	}
+}
+
+// write adds s to the paragraph's text. Text inside a complex field's code
+// is not shown, only its result.
+func (t *docxText) write(s string) {
+	if n := len(t.fields); n > 0 && !t.fields[n-1].shown {
+		return
+	}
+	t.text.WriteString(s)
+}
+
+func (t *docxText) fieldChar(c *wml.CT_FldChar) {
+	switch c.FldCharTypeAttr {
+	case wml.ST_FldCharTypeBegin:
+		t.fields = append(t.fields, &docxField{})
+	case wml.ST_FldCharTypeSeparate:
+		if n := len(t.fields); n > 0 {
+			t.fields[n-1].shown = true
+			t.fields[n-1].result = t.text.Len()
+		}
+	case wml.ST_FldCharTypeEnd:
+		t.endField()
+	}
+}
+
+func (t *docxText) endField() {
+	n := len(t.fields)
+	if n == 0 {
+		return
+	}
+	field := t.fields[n-1]
+	t.fields = t.fields[:n-1]
+	shown := ""
+	if field.shown {
+		shown = t.text.String()[field.result:]
+	}
+	t.fieldLink(field.code.String(), shown)
+}
+
+// fieldLink records the target of a HYPERLINK field, which some editors
+// write instead of a hyperlink element.
+func (t *docxText) fieldLink(code, shown string) {
+	if m := hyperlinkField.FindStringSubmatch(code); m != nil {
+		t.links = append(t.links, link{text: shown, url: m[1]})
+	}
+}
+
+// noteMark numbers a note by the order of its first reference and returns
+// its mark, e.g. [1], or [e1] for an endnote.
+func noteMark(refs *[]int64, id int64, prefix string) string {
+	n := 0
+	for i, ref := range *refs {
+		if ref == id {
+			n = i + 1
+		}
+	}
+	if n == 0 {
+		*refs = append(*refs, id)
+		n = len(*refs)
+	}
+	return fmt.Sprintf("[%s%d]", prefix, n)
+}
+
+// notes reads the referenced notes in the order of their marks, each on a
+// line of its own after its mark.
+func (t *docxText) notes(refs []int64, notes []*wml.CT_FtnEdn, prefix string) []string {
+	var lines []string
+	for i, id := range refs {
+		for _, note := range notes {
+			if note.IdAttr != id {
+				continue
+			}
+			text := t.capture(func() { t.blockElts(note.EG_BlockLevelElts) })
+			line := strings.Join(strings.Fields(strings.Join(text, " ")), " ")
+			lines = append(lines, fmt.Sprintf("[%s%d] %s", prefix, i+1, line))
+			break
+		}
+	}
+	return lines
+}
+
+// table reads a table row by row. A row of one-line cells becomes one line
+// with the cells separated by " | "; a row with longer cells, such as a
+// layout table holding a sidebar, is read cell by cell.
+func (t *docxText) table(tbl *wml.CT_Tbl) {
+	for _, row := range tableRows(tbl.EG_ContentRowContent) {
+		var cells [][]string
+		short := true
+		for _, cell := range rowCells(row.EG_ContentCellContent) {
+			lines := trimBlankLines(t.capture(func() { t.blockElts(cell.EG_BlockLevelElts) }))
+			if len(lines) == 0 {
+				continue
+			}
+			short = short && len(lines) == 1
+			cells = append(cells, lines)
+		}
+
+		if short {
+			var line []string
+			for _, cell := range cells {
+				line = append(line, cell[0])
+			}
+			if len(line) > 0 {
+				t.lines = append(t.lines, strings.Join(line, " | "))
+			}
+			continue
+		}
+		for _, cell := range cells {
+			t.lines = append(t.lines, "")
+			t.lines = append(t.lines, cell...)
+		}
+		t.lines = append(t.lines, "")
+	}
+}
+
+func tableRows(contents []*wml.EG_ContentRowContent) []*wml.CT_Row {
+	var rows []*wml.CT_Row
+	for _, content := range contents {
+		rows = append(rows, content.Tr...)
+		if content.CustomXml != nil {
+			rows = append(rows, tableRows(content.CustomXml.EG_ContentRowContent)...)
+		}
+		for sdt := content.Sdt; sdt != nil && sdt.SdtContent != nil; sdt = sdt.SdtContent.Sdt {
+			rows = append(rows, sdt.SdtContent.Tr...)
+		}
+	}
+	return rows
+}
+
+func rowCells(contents []*wml.EG_ContentCellContent) []*wml.CT_Tc {
+	var cells []*wml.CT_Tc
+	for _, content := range contents {
+		cells = append(cells, content.Tc...)
+		if content.CustomXml != nil {
+			cells = append(cells, rowCells(content.CustomXml.EG_ContentCellContent)...)
+		}
+		for sdt := content.Sdt; sdt != nil && sdt.SdtContent != nil; sdt = sdt.SdtContent.Sdt {
+			cells = append(cells, sdt.SdtContent.Tc...)
+		}
+	}
+	return cells
+}
+
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// drawing queues the text boxes in a DrawingML drawing, inline or floating.
+func (t *docxText) drawing(d *wml.CT_Drawing) {
+	for _, anchor := range d.Anchor {
+		t.graphic(anchor.Graphic)
+	}
+	for _, inline := range d.Inline {
+		t.graphic(inline.Graphic)
+	}
+}
+
+func (t *docxText) graphic(g *dml.Graphic) {
+	if g == nil || g.GraphicData == nil {
+		return
+	}
+	for _, item := range g.GraphicData.Any {
+		switch item := item.(type) {
+		case *wml.WdWsp:
+			t.shape(&item.WdCT_WordprocessingShape)
+		case *wml.WdWgp:
+			t.group(&item.WdCT_WordprocessingGroup)
+		}
+	}
+}
+
+func (t *docxText) shape(s *wml.WdCT_WordprocessingShape) {
+	if s.WChoice != nil && s.WChoice.Txbx != nil && s.WChoice.Txbx.TxbxContent != nil {
+		t.boxes = append(t.boxes, s.WChoice.Txbx.TxbxContent.EG_ContentBlockContent)
+	}
+}
+
+func (t *docxText) group(g *wml.WdCT_WordprocessingGroup) {
+	for _, choice := range g.Choice {
+		for _, s := range choice.Wsp {
+			t.shape(&s.WdCT_WordprocessingShape)
+		}
+		for _, sub := range choice.GrpSp {
+			t.group(sub)
+		}
+	}
+}
+
+// picture queues the text boxes in a VML picture, as older documents
+// write them.
+func (t *docxText) picture(p *wml.CT_Picture) {
+	for _, item := range p.Any {
+		t.vmlShape(item)
+	}
+}
+
+func (t *docxText) vmlShape(item any) {
+	switch item := item.(type) {
+	case *vml.Shape:
+		t.vmlTextboxes(item.EG_ShapeElements)
+	case *vml.Rect:
+		t.vmlTextboxes(item.EG_ShapeElements)
+	case *vml.Roundrect:
+		t.vmlTextboxes(item.EG_ShapeElements)
+	case *vml.Group:
+		t.vmlTextboxes(item.EG_ShapeElements)
+		for _, s := range item.Shape {
+			t.vmlShape(s)
+		}
+		for _, r := range item.Rect {
+			t.vmlShape(r)
+		}
+		for _, r := range item.Roundrect {
+			t.vmlShape(r)
+		}
+		for _, g := range item.Group {
+			t.vmlShape(g)
+		}
+	}
+}
+
+func (t *docxText) vmlTextboxes(elements []*vml.EG_ShapeElements) {
+	for _, e := range elements {
+		if e.Textbox != nil && e.Textbox.TxbxContent != nil {
+			t.boxes = append(t.boxes, e.Textbox.TxbxContent.EG_ContentBlockContent)
+		}
+	}
+}
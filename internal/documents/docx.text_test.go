@@ -0,0 +1,145 @@
+package documents
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/unidoc/unioffice"
+	"github.com/unidoc/unioffice/document"
+	"github.com/unidoc/unioffice/schema/soo/dml"
+	"github.com/unidoc/unioffice/schema/soo/wml"
+)
+
+func TestJoinBlocks(t *testing.T) {
+	tests := []struct {
+		name   string
+		blocks [][]string
+		want   string
+	}{
+		{"none", nil, ""},
+		{"blocks a blank line apart", [][]string{{"Header"}, {"Body", "More"}}, "Header\n\nBody\nMore\n"},
+		{"empty blocks left out", [][]string{{"Header"}, {}, {"", " "}, {"Footer"}}, "Header\n\nFooter\n"},
+		{"repeated block left out", [][]string{{"Ada Okafor"}, {"Body"}, {"Ada Okafor"}}, "Ada Okafor\n\nBody\n"},
+		{"blank runs collapsed", [][]string{{"", "One", "", "", "Two"}}, "One\n\nTwo\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := joinBlocks(tt.blocks); got != tt.want {
+				t.Errorf("joinBlocks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoteMark(t *testing.T) {
+	var refs []int64
+	var marks []string
+	for _, id := range []int64{7, 3, 7, 9} {
+		marks = append(marks, noteMark(&refs, id, "e"))
+	}
+	want := []string{"[e1]", "[e2]", "[e1]", "[e3]"}
+	if !reflect.DeepEqual(marks, want) {
+		t.Errorf("marks = %v, want %v", marks, want)
+	}
+	if !reflect.DeepEqual(refs, []int64{7, 3, 9}) {
+		t.Errorf("refs = %v, want [7 3 9]", refs)
+	}
+}
+
+func TestExtractDOCXTextOrder(t *testing.T) {
+	doc := document.New()
+	defer doc.Close()
+
+	doc.AddHeader().AddParagraph().AddRun().AddText("Ada Okafor")
+	// a first-page header repeating the default one is read once
+	doc.AddHeader().AddParagraph().AddRun().AddText("Ada Okafor")
+	doc.AddFooter().AddParagraph().AddRun().AddText("Page 1")
+
+	run := doc.AddParagraph().AddRun()
+	run.AddText("Summary")
+	run.AddBreak()
+	run.AddText("Backend   engineer")
+
+	// one-line cells make a row of one line
+	contact := doc.AddTable()
+	row := contact.AddRow()
+	row.AddCell().AddParagraph().AddRun().AddText("ada@example.com")
+	row.AddCell()
+	row.AddCell().AddParagraph().AddRun().AddText("Lagos")
+
+	// longer cells, as in a layout table with a sidebar, are read in turn
+	layout := doc.AddTable()
+	row = layout.AddRow()
+	sidebar := row.AddCell()
+	sidebar.AddParagraph().AddRun().AddText("Skills")
+	sidebar.AddParagraph().AddRun().AddText("Go")
+	main := row.AddCell()
+	main.AddParagraph().AddRun().AddText("Experience")
+	main.AddParagraph().AddRun().AddText("Acme")
+
+	got, links := extractDOCXText(doc, headerFooterRels{})
+	want := "Ada Okafor\n\n" +
+		"Summary\nBackend engineer\n" +
+		"ada@example.com | Lagos\n\n" +
+		"Skills\nGo\n\n" +
+		"Experience\nAcme\n\n" +
+		"Page 1\n"
+	if got != want {
+		t.Errorf("extractDOCXText =\n%s\nwant\n%s", got, want)
+	}
+	if len(links) != 0 {
+		t.Errorf("links = %v, want none", links)
+	}
+}
+
+// runParagraph is a paragraph of one run.
+func runParagraph(content ...*wml.EG_RunInnerContent) *wml.CT_P {
+	return &wml.CT_P{EG_PContent: []*wml.EG_PContent{{EG_ContentRunContent: []*wml.EG_ContentRunContent{{
+		R: &wml.CT_R{EG_RunInnerContent: content},
+	}}}}}
+}
+
+func TestDOCXTextParagraph(t *testing.T) {
+	text := func(s string) *wml.EG_RunInnerContent {
+		return &wml.EG_RunInnerContent{T: &wml.CT_Text{Content: s}}
+	}
+	field := func(kind wml.ST_FldCharType) *wml.EG_RunInnerContent {
+		return &wml.EG_RunInnerContent{FldChar: &wml.CT_FldChar{FldCharTypeAttr: kind}}
+	}
+	// a floating text box, read after the paragraph it is anchored in
+	box := &wml.EG_RunInnerContent{Drawing: &wml.CT_Drawing{Anchor: []*wml.WdAnchor{{WdCT_Anchor: wml.WdCT_Anchor{
+		Graphic: &dml.Graphic{CT_GraphicalObject: dml.CT_GraphicalObject{GraphicData: &dml.CT_GraphicalObjectData{
+			Any: []unioffice.Any{&wml.WdWsp{WdCT_WordprocessingShape: wml.WdCT_WordprocessingShape{
+				WChoice: &wml.WdCT_WordprocessingShapeChoice1{Txbx: &wml.WdCT_TextboxInfo{TxbxContent: &wml.WdCT_TxbxContent{
+					EG_ContentBlockContent: []*wml.EG_ContentBlockContent{{P: []*wml.CT_P{runParagraph(text("Open to relocation"))}}},
+				}}},
+			}}},
+		}}},
+	}}}}}
+
+	p := runParagraph(
+		text("GitHub: "),
+		field(wml.ST_FldCharTypeBegin),
+		&wml.EG_RunInnerContent{InstrText: &wml.CT_Text{Content: ` HYPERLINK "https://github.com/ada" `}},
+		field(wml.ST_FldCharTypeSeparate),
+		text("ada"),
+		field(wml.ST_FldCharTypeEnd),
+		&wml.EG_RunInnerContent{Tab: &wml.CT_Empty{}},
+		text("see"),
+		&wml.EG_RunInnerContent{FootnoteReference: &wml.CT_FtnEdnRef{IdAttr: 4}},
+		box,
+	)
+
+	tx := &docxText{lists: newListNumbers(nil)}
+	tx.paragraph(p)
+
+	if want := []string{"GitHub: ada see[1]", "", "Open to relocation", ""}; !reflect.DeepEqual(tx.lines, want) {
+		t.Errorf("lines = %q, want %q", tx.lines, want)
+	}
+	if want := []link{{text: "ada", url: "https://github.com/ada"}}; !reflect.DeepEqual(tx.links, want) {
+		t.Errorf("links = %v, want %v", tx.links, want)
+	}
+	if want := []int64{4}; !reflect.DeepEqual(tx.footnotes, want) {
+		t.Errorf("footnotes = %v, want %v", tx.footnotes, want)
+	}
+}
@@ -0,0 +1,288 @@
+package documents
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/richardlehane/mscfb"
+)
+
+// Errors about the uploaded file itself rather than its processing. Callers
+// tell them apart with errors.Is.
+var (
+	ErrUnsupportedFormat = errors.New("unsupported file format")
+	ErrFormatMismatch    = errors.New("file content does not match its extension")
+	ErrCorruptFile       = errors.New("file is corrupt")
+	ErrEncryptedFile     = errors.New("file is encrypted")
+)
+
+// TextExtractor reads the text of a CV in one input format.
+type TextExtractor interface {
+	ExtractText(ctx context.Context, file io.Reader) (string, error)
+}
+
+// Format is an input format a CV can be uploaded in.
+type Format struct {
+	Name      string
+	MediaType string
+	// Extensions are the file name extensions of the format, lowercase and
+	// with the leading dot; the first is the usual one.
+	Extensions []string
+	// ContentTypes are the media types the file's content may sniff as.
+	// "text/plain" also covers the kinds of text it is the parent of, such
+	// as HTML, CSV or XML.
+	ContentTypes []string
+	Extractor    TextExtractor
+}
+
+// accepts reports whether content sniffed as m can be a file of the format.
+func (f *Format) accepts(m *mimetype.MIME) bool {
+	for _, t := range f.ContentTypes {
+		if m.Is(t) {
+			return true
+		}
+		if t == "text/plain" && isText(m) {
+			return true
+		}
+	}
+	return false
+}
+
+func isText(m *mimetype.MIME) bool {
+	for ; m != nil; m = m.Parent() {
+		if m.Is("text/plain") {
+			return true
+		}
+	}
+	return false
+}
+
+var builtinFormats = []Format{
+	{Name: "pdf", MediaType: "application/pdf", Extensions: []string{".pdf"}, ContentTypes: []string{"application/pdf"}, Extractor: NewPDFProcessor()},
+	{Name: "docx", MediaType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", Extensions: []string{".docx"}, ContentTypes: []string{"application/vnd.openxmlformats-officedocument.wordprocessingml.document"}, Extractor: NewDOCXProcessor()},
+	// the root entry that names a compound file a Word document is often
+	// past the bytes sniffed
+	{Name: "doc", MediaType: "application/msword", Extensions: []string{".doc"}, ContentTypes: []string{"application/msword", "application/x-ole-storage"}, Extractor: NewDOCExtractor()},
+	{Name: "odt", MediaType: "application/vnd.oasis.opendocument.text", Extensions: []string{".odt"}, ContentTypes: []string{"application/vnd.oasis.opendocument.text"}, Extractor: NewODTExtractor()},
+	{Name: "rtf", MediaType: "text/rtf", Extensions: []string{".rtf"}, ContentTypes: []string{"text/rtf"}, Extractor: NewRTFExtractor()},
+	{Name: "html", MediaType: "text/html", Extensions: []string{".html", ".htm"}, ContentTypes: []string{"text/plain"}, Extractor: NewHTMLExtractor()},
+	{Name: "md", MediaType: "text/markdown", Extensions: []string{".md", ".markdown"}, ContentTypes: []string{"text/plain"}, Extractor: NewMarkdownExtractor()},
+	{Name: "txt", MediaType: "text/plain", Extensions: []string{".txt"}, ContentTypes: []string{"text/plain"}, Extractor: NewPlainTextExtractor()},
+}
+
+// FormatRegistry holds the input formats CVs are accepted in, by name and by
+// file name extension.
+type FormatRegistry struct {
+	mu         sync.RWMutex
+	formats    map[string]*Format
+	extensions map[string]*Format
+}
+
+// NewFormatRegistry returns a registry holding the built-in formats.
+func NewFormatRegistry() *FormatRegistry {
+	r := &FormatRegistry{
+		formats:    make(map[string]*Format),
+		extensions: make(map[string]*Format),
+	}
+	for i := range builtinFormats {
+		f := builtinFormats[i]
+		f.Extensions = append([]string(nil), f.Extensions...)
+		if err := r.Register(&f); err != nil {
+			panic(err) // built-ins are static, so this is a programming error
+		}
+	}
+	return r
+}
+
+// Register adds or replaces a format and claims its extensions.
+func (r *FormatRegistry) Register(f *Format) error {
+	if f.Name == "" {
+		return fmt.Errorf("format has no name")
+	}
+	if f.Extractor == nil {
+		return fmt.Errorf("format %s: no extractor", f.Name)
+	}
+	if len(f.Extensions) == 0 {
+		return fmt.Errorf("format %s: no extensions", f.Name)
+	}
+	if len(f.ContentTypes) == 0 {
+		return fmt.Errorf("format %s: no content types", f.Name)
+	}
+	for i, ext := range f.Extensions {
+		if !strings.HasPrefix(ext, ".") {
+			return fmt.Errorf("format %s: extension %q must start with a dot", f.Name, ext)
+		}
+		f.Extensions[i] = strings.ToLower(ext)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := strings.ToLower(f.Name)
+	if old, ok := r.formats[name]; ok {
+		for _, ext := range old.Extensions {
+			delete(r.extensions, ext)
+		}
+	}
+	r.formats[name] = f
+	for _, ext := range f.Extensions {
+		r.extensions[ext] = f
+	}
+	return nil
+}
+
+// Get looks a format up by name.
+func (r *FormatRegistry) Get(name string) (*Format, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.formats[strings.ToLower(name)]
+	return f, ok
+}
+
+// ForExtension looks a format up by a file name extension such as ".pdf".
+func (r *FormatRegistry) ForExtension(ext string) (*Format, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.extensions[strings.ToLower(ext)]
+	return f, ok
+}
+
+// Detect decides the format of an uploaded file from its content. The
+// content of a file whose name has an extension must be of that extension's
+// format; a file without one is taken as the format its content sniffs as.
+// Errors wrap ErrUnsupportedFormat, ErrFormatMismatch, ErrCorruptFile or
+// ErrEncryptedFile.
+func (r *FormatRegistry) Detect(data []byte, filename string) (*Format, error) {
+	sniffed := sniff(data)
+	ext := filepath.Ext(filename)
+	if ext == "" {
+		if f, ok := r.forContent(sniffed); ok {
+			return f, nil
+		}
+		return nil, fmt.Errorf("%w: content is %s", ErrUnsupportedFormat, sniffed)
+	}
+
+	f, ok := r.ForExtension(ext)
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, strings.ToLower(ext))
+	case f.accepts(sniffed):
+		return f, nil
+	case sniffed.Is("application/octet-stream"):
+		// nothing recognizable, not even text
+		return nil, fmt.Errorf("%w: not a %s file", ErrCorruptFile, f.Name)
+	case isEncryptedPackage(data, sniffed):
+		return nil, fmt.Errorf("%w: %s", ErrEncryptedFile, filename)
+	}
+	return nil, fmt.Errorf("%w: %s holds %s", ErrFormatMismatch, filename, sniffed)
+}
+
+// sniff detects the media type of data. A zip is looked into, since a DOCX
+// or ODT whose parts are not in the order their editors write them only
+// sniffs as a zip from its first bytes.
+func sniff(data []byte) *mimetype.MIME {
+	sniffed := mimetype.Detect(data)
+	if !sniffed.Is("application/zip") {
+		return sniffed
+	}
+	if t := packageType(data); t != "" {
+		if m := mimetype.Lookup(t); m != nil {
+			return m
+		}
+	}
+	return sniffed
+}
+
+// packageType names the kind of document a zip package holds from its
+// parts: a Word document has word/document.xml and an OpenDocument file
+// names its type in its mimetype entry. It is "" for any other zip.
+func packageType(data []byte) string {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return ""
+	}
+	for _, file := range archive.File {
+		switch file.Name {
+		case "word/document.xml":
+			return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+		case "mimetype":
+			entry, err := file.Open()
+			if err != nil {
+				return ""
+			}
+			name, err := io.ReadAll(io.LimitReader(entry, 128))
+			entry.Close()
+			if err != nil {
+				return ""
+			}
+			return strings.TrimSpace(string(name))
+		}
+	}
+	return ""
+}
+
+// forContent finds the format whose media type content sniffed as m is, or
+// is a kind of.
+func (r *FormatRegistry) forContent(m *mimetype.MIME) (*Format, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for ; m != nil; m = m.Parent() {
+		for _, f := range r.formats {
+			if m.Is(f.MediaType) {
+				return f, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// isEncryptedPackage reports whether a compound file holds a password
+// protected Office Open XML package, which is how Word saves an encrypted
+// DOCX.
+func isEncryptedPackage(data []byte, m *mimetype.MIME) bool {
+	if !m.Is("application/x-ole-storage") {
+		return false
+	}
+	cfb, err := mscfb.New(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+	for entry, err := cfb.Next(); err == nil; entry, err = cfb.Next() {
+		if entry.Name == "EncryptedPackage" {
+			return true
+		}
+	}
+	return false
+}
+
+// Names lists the registered format names in alphabetical order.
+func (r *FormatRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.formats))
+	for name := range r.formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Extensions lists the accepted file name extensions in alphabetical order.
+func (r *FormatRegistry) Extensions() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	exts := make([]string, 0, len(r.extensions))
+	for ext := range r.extensions {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
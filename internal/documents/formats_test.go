@@ -0,0 +1,71 @@
+package documents
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// zipOf writes a zip holding the named entries, in order.
+func zipOf(t *testing.T, entries ...[2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		f, err := w.Create(entry[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(entry[1]))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectZipPackages(t *testing.T) {
+	// parts in an order no editor writes, so the first bytes only say zip
+	docx := zipOf(t, [2]string{"docProps/app.xml", "<Properties/>"}, [2]string{"word/document.xml", "<w:document/>"}, [2]string{"[Content_Types].xml", "<Types/>"})
+	odt := zipOf(t, [2]string{"content.xml", "<office:document-content/>"}, [2]string{"mimetype", "application/vnd.oasis.opendocument.text"})
+	ods := zipOf(t, [2]string{"content.xml", "<office:document-content/>"}, [2]string{"mimetype", "application/vnd.oasis.opendocument.spreadsheet"})
+	archive := zipOf(t, [2]string{"cv.txt", "Ada Okafor"}, [2]string{"photo.jpg", "\xff\xd8\xff"})
+
+	tests := []struct {
+		name     string
+		data     []byte
+		filename string
+		want     string
+		err      error
+	}{
+		{"docx", docx, "cv.docx", "docx", nil},
+		{"docx without extension", docx, "cv", "docx", nil},
+		{"odt", odt, "cv.odt", "odt", nil},
+		{"odt without extension", odt, "cv", "odt", nil},
+		{"docx named odt", docx, "cv.odt", "", ErrFormatMismatch},
+		{"odt named docx", odt, "cv.docx", "", ErrFormatMismatch},
+		{"spreadsheet named odt", ods, "cv.odt", "", ErrFormatMismatch},
+		{"plain zip named docx", archive, "cv.docx", "", ErrFormatMismatch},
+		{"plain zip named odt", archive, "cv.odt", "", ErrFormatMismatch},
+		{"plain zip without extension", archive, "cv", "", ErrUnsupportedFormat},
+	}
+	formats := NewFormatRegistry()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := formats.Detect(tt.data, tt.filename)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Detect = %v, %v; want %v", f, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Detect: %v", err)
+			}
+			if f.Name != tt.want {
+				t.Errorf("Detect = %s, want %s", f.Name, tt.want)
+			}
+		})
+	}
+}
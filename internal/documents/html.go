@@ -0,0 +1,256 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// htmlSkipped are elements whose content is never shown as text.
+var htmlSkipped = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true,
+	atom.Template: true, atom.Svg: true, atom.Math: true, atom.Iframe: true,
+	atom.Object: true, atom.Select: true, atom.Button: true,
+}
+
+// htmlBlocks are elements that start on a line of their own; the ones mapped
+// to true are also set off by a blank line.
+var htmlBlocks = map[atom.Atom]bool{
+	atom.P: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true,
+	atom.H5: true, atom.H6: true, atom.Section: true, atom.Article: true,
+	atom.Header: true, atom.Footer: true, atom.Main: true, atom.Aside: true,
+	atom.Nav: true, atom.Table: true, atom.Ul: true, atom.Ol: true,
+	atom.Dl: true, atom.Blockquote: true, atom.Pre: true, atom.Address: true,
+	atom.Figure: true, atom.Form: true, atom.Fieldset: true, atom.Hr: true,
+	atom.Div: false, atom.Li: false, atom.Dt: false, atom.Dd: false,
+	atom.Tr: false, atom.Caption: false, atom.Figcaption: false,
+	atom.Legend: false, atom.Details: false, atom.Summary: false,
+}
+
+type HTMLExtractor struct{}
+
+func NewHTMLExtractor() *HTMLExtractor {
+	return &HTMLExtractor{}
+}
+
+// ExtractText reads an HTML CV, such as a saved web page or an export from
+// an online CV builder, as the text a browser shows: blocks and list items
+// on lines of their own and table rows as cells joined by " | ", followed by
+// the targets of its links.
+func (e *HTMLExtractor) ExtractText(ctx context.Context, file io.Reader) (string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("reading HTML file: %w", err)
+	}
+	// the encoding comes from a byte order mark or a <meta charset> tag
+	reader, err := charset.NewReader(bytes.NewReader(data), "text/html")
+	if err != nil {
+		return "", fmt.Errorf("decoding HTML file: %w", err)
+	}
+	doc, err := html.Parse(reader)
+	if err != nil {
+		return "", fmt.Errorf("parsing HTML: %w", err)
+	}
+
+	w := &htmlText{}
+	w.node(doc)
+	w.breakLine()
+	return appendLinks(joinLines(w.lines), w.links), nil
+}
+
+// htmlText collects the text of an HTML tree in lines.
+type htmlText struct {
+	lines []string
+	line  strings.Builder
+	// space is a collapsed run of white space waiting to be written
+	space bool
+	pre   int
+	links []link
+}
+
+func (w *htmlText) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	case html.DocumentNode:
+		w.children(n)
+		return
+	default:
+		return
+	}
+
+	if htmlSkipped[n.DataAtom] || hasAttr(n, "hidden") {
+		return
+	}
+	switch n.DataAtom {
+	case atom.Br:
+		// a <br> on an empty line leaves a blank one
+		if w.line.Len() == 0 {
+			w.lines = append(w.lines, "")
+		}
+		w.breakLine()
+		return
+	case atom.Img:
+		w.text(attr(n, "alt"))
+		return
+	case atom.Tr:
+		w.row(n)
+		return
+	case atom.Ol, atom.Ul:
+		w.list(n)
+		return
+	case atom.A:
+		start := len(w.lines)
+		before := w.line.String()
+		w.children(n)
+		if href := strings.TrimSpace(attr(n, "href")); href != "" {
+			w.links = append(w.links, link{text: textSince(w.lines, w.line.String(), start, before), url: href})
+		}
+		return
+	case atom.Pre:
+		w.pre++
+		defer func() { w.pre-- }()
+	}
+
+	blank, block := htmlBlocks[n.DataAtom]
+	if block {
+		w.startBlock(blank)
+	}
+	w.children(n)
+	if block {
+		w.startBlock(blank)
+	}
+}
+
+func (w *htmlText) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+// list writes each item of a list on a line of its own, after a bullet or
+// its number.
+func (w *htmlText) list(n *html.Node) {
+	w.startBlock(true)
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = start
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			w.node(c)
+			continue
+		}
+		w.breakLine()
+		if value, err := strconv.Atoi(attr(c, "value")); err == nil {
+			number = value
+		}
+		if n.DataAtom == atom.Ol {
+			w.line.WriteString(strconv.Itoa(number) + ". ")
+		} else {
+			w.line.WriteString("• ")
+		}
+		number++
+		w.space = false
+		w.children(c)
+		w.breakLine()
+	}
+	w.startBlock(true)
+}
+
+// row writes a table row on one line, its cells joined by " | ".
+func (w *htmlText) row(n *html.Node) {
+	w.breakLine()
+	var cells []string
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || (c.DataAtom != atom.Td && c.DataAtom != atom.Th) {
+			continue
+		}
+		cell := &htmlText{pre: w.pre}
+		cell.children(c)
+		cell.breakLine()
+		w.links = append(w.links, cell.links...)
+		if text := strings.Join(strings.Fields(strings.Join(cell.lines, " ")), " "); text != "" {
+			cells = append(cells, text)
+		}
+	}
+	if len(cells) > 0 {
+		w.lines = append(w.lines, strings.Join(cells, " | "))
+	}
+}
+
+// text writes a text node, collapsing white space outside <pre>.
+func (w *htmlText) text(s string) {
+	if w.pre > 0 {
+		parts := strings.Split(s, "\n")
+		for i, part := range parts {
+			if i > 0 {
+				w.breakLine()
+			}
+			w.line.WriteString(part)
+		}
+		return
+	}
+	for i, field := range strings.Fields(s) {
+		if (i > 0 || w.space || startsWithSpace(s)) && w.line.Len() > 0 {
+			w.line.WriteString(" ")
+		}
+		w.line.WriteString(field)
+		w.space = false
+	}
+	if s != "" && endsWithSpace(s) {
+		w.space = true
+	}
+}
+
+func (w *htmlText) breakLine() {
+	if w.line.Len() > 0 {
+		w.lines = append(w.lines, strings.TrimRight(w.line.String(), " \t"))
+	}
+	w.line.Reset()
+	w.space = false
+}
+
+// startBlock ends the current line, if any, and with blank also leaves an
+// empty line.
+func (w *htmlText) startBlock(blank bool) {
+	w.breakLine()
+	if blank && len(w.lines) > 0 && w.lines[len(w.lines)-1] != "" {
+		w.lines = append(w.lines, "")
+	}
+}
+
+func startsWithSpace(s string) bool {
+	return s != "" && strings.TrimLeft(s[:1], " \t\r\n\f") == ""
+}
+
+func endsWithSpace(s string) bool {
+	return s != "" && strings.TrimRight(s[len(s)-1:], " \t\r\n\f") == ""
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package documents
+
+import (
+	"net/url"
+	"strings"
+)
+
+// link is a hyperlink in a CV: the address it points at and the text it is
+// anchored to, which is often just "LinkedIn" or "Portfolio".
+type link struct {
+	text, url string
+}
+
+// linksHeading starts the block appendLinks adds after a CV's text; the
+// resume prompt tells the model to take header URLs from it.
+const linksHeading = "Links:"
+
+// appendLinks lists the web and mail links of a CV after its text, one per
+// line as "- text: url", so the model sees the addresses behind display text.
+// Each address is listed once, and links to anything else, such as a place
+// in the document, are left out.
+func appendLinks(text string, links []link) string {
+	seen := make(map[string]bool)
+	var lines []string
+	for _, l := range links {
+		address := strings.TrimSpace(l.url)
+		if !isWebLink(address) || seen[address] {
+			continue
+		}
+		seen[address] = true
+
+		label := strings.Join(strings.Fields(l.text), " ")
+		if label == "" || label == address {
+			lines = append(lines, "- "+address)
+		} else {
+			lines = append(lines, "- "+label+": "+address)
+		}
+	}
+	if len(lines) == 0 {
+		return text
+	}
+
+	text = strings.TrimRight(text, "\n")
+	return text + "\n\n" + linksHeading + "\n" + strings.Join(lines, "\n") + "\n"
+}
+
+func isWebLink(address string) bool {
+	u, err := url.Parse(address)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return u.Opaque != ""
+	default:
+		return false
+	}
+}
+
+// textSince returns the text written since a link's text started at
+// startText in line startLine, given the lines and the line being written.
+func textSince(lines []string, current string, startLine int, startText string) string {
+	if startLine >= len(lines) {
+		return strings.TrimPrefix(current, startText)
+	}
+	parts := append([]string{strings.TrimPrefix(lines[startLine], strings.TrimSpace(startText))}, lines[startLine+1:]...)
+	return strings.Join(append(parts, current), " ")
+}
@@ -0,0 +1,160 @@
+package documents
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	mdFence      = regexp.MustCompile("^\\s*(```|~~~)")
+	mdHeading    = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+	mdRule       = regexp.MustCompile(`^\s{0,3}[-*_=](\s*[-*_=]){2,}\s*$`)
+	mdQuote      = regexp.MustCompile(`^\s{0,3}>\s?`)
+	mdBullet     = regexp.MustCompile(`^(\s*)[-*+]\s+(\[[ xX]\]\s+)?`)
+	mdTableRule  = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdDefinition = regexp.MustCompile(`^\s{0,3}\[([^\]]+)\]:\s*<?(\S+?)>?(\s+["'(].*)?$`)
+	mdImage      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdInlineLink = regexp.MustCompile(`\[([^\]]+)\]\(\s*<?([^)\s>]+)>?(?:\s+["'(][^)]*)?\)`)
+	mdRefLink    = regexp.MustCompile(`\[([^\]]+)\]\[([^\]]*)\]`)
+	mdAutolink   = regexp.MustCompile(`<((?:https?://|mailto:)[^>\s]+)>`)
+	mdTag        = regexp.MustCompile(`</?[A-Za-z][^>]*>`)
+	mdStrong     = regexp.MustCompile(`(\*\*|__)(\S(?:.*?\S)?)(\*\*|__)`)
+	mdEmphasis   = regexp.MustCompile(`(^|[^\w*])[*_](\S(?:.*?\S)?)[*_]($|[^\w*])`)
+	mdStrike     = regexp.MustCompile(`~~(.+?)~~`)
+	mdCode       = regexp.MustCompile("`+([^`]+)`+")
+	mdEscape     = regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!|>~])`)
+)
+
+type MarkdownExtractor struct{}
+
+func NewMarkdownExtractor() *MarkdownExtractor {
+	return &MarkdownExtractor{}
+}
+
+// ExtractText reads a Markdown CV as the text it renders to: headings, list
+// items and table rows on lines of their own, without the markup, followed
+// by the targets of its links.
+func (e *MarkdownExtractor) ExtractText(ctx context.Context, file io.Reader) (string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("reading Markdown file: %w", err)
+	}
+	source, err := decodeText(data)
+	if err != nil {
+		return "", fmt.Errorf("decoding Markdown file: %w", err)
+	}
+
+	lines := strings.Split(source, "\n")
+	// reference links name their address anywhere in the file
+	refs := make(map[string]string)
+	for _, line := range lines {
+		if m := mdDefinition.FindStringSubmatch(line); m != nil {
+			refs[strings.ToLower(m[1])] = m[2]
+		}
+	}
+
+	var out []string
+	var links []link
+	inFence := false
+	for _, line := range lines {
+		if mdFence.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			out = append(out, strings.TrimRight(line, " \t"))
+			continue
+		}
+		if mdDefinition.MatchString(line) || mdRule.MatchString(line) || mdTableRule.MatchString(line) && strings.Contains(line, "|") {
+			continue
+		}
+
+		for mdQuote.MatchString(line) {
+			line = mdQuote.ReplaceAllString(line, "")
+		}
+		if m := mdHeading.FindStringSubmatch(line); m != nil {
+			line = m[1]
+		}
+		line = mdBullet.ReplaceAllString(line, "$1• ")
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "|") || strings.HasSuffix(trimmed, "|") && strings.Count(trimmed, "|") > 1 {
+			line = markdownTableRow(trimmed)
+		}
+
+		var found []link
+		line, found = markdownInline(line, refs)
+		links = append(links, found...)
+		out = append(out, strings.TrimRight(line, " \t"))
+	}
+
+	return appendLinks(joinLines(out), links), nil
+}
+
+// markdownInline strips the inline markup from a line and returns the links
+// in it.
+func markdownInline(line string, refs map[string]string) (string, []link) {
+	var links []link
+	line = mdImage.ReplaceAllString(line, "$1")
+	line = mdInlineLink.ReplaceAllStringFunc(line, func(s string) string {
+		m := mdInlineLink.FindStringSubmatch(s)
+		links = append(links, link{text: m[1], url: m[2]})
+		return m[1]
+	})
+	line = mdRefLink.ReplaceAllStringFunc(line, func(s string) string {
+		m := mdRefLink.FindStringSubmatch(s)
+		ref := m[2]
+		if ref == "" {
+			ref = m[1]
+		}
+		if url, ok := refs[strings.ToLower(ref)]; ok {
+			links = append(links, link{text: m[1], url: url})
+		}
+		return m[1]
+	})
+	line = mdAutolink.ReplaceAllStringFunc(line, func(s string) string {
+		url := mdAutolink.FindStringSubmatch(s)[1]
+		links = append(links, link{url: url})
+		return strings.TrimPrefix(url, "mailto:")
+	})
+	line = mdTag.ReplaceAllString(line, "")
+	line = mdCode.ReplaceAllString(line, "$1")
+	line = mdStrong.ReplaceAllString(line, "$2")
+	line = mdEmphasis.ReplaceAllString(line, "$1$2$3")
+	line = mdStrike.ReplaceAllString(line, "$1")
+	line = mdEscape.ReplaceAllString(line, "$1")
+	return line, links
+}
+
+// markdownTableRow joins the cells of a table row with " | ".
+func markdownTableRow(row string) string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	var cells []string
+	for _, cell := range strings.Split(row, "|") {
+		if cell = strings.TrimSpace(cell); cell != "" {
+			cells = append(cells, cell)
+		}
+	}
+	return strings.Join(cells, " | ")
+}
+
+// joinLines joins lines, dropping blank lines at the ends and collapsing
+// runs of them into one.
+func joinLines(lines []string) string {
+	var text strings.Builder
+	blank := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			blank = text.Len() > 0
+			continue
+		}
+		if blank {
+			text.WriteString("\n")
+			blank = false
+		}
+		text.WriteString(line)
+		text.WriteString("\n")
+	}
+	return text.String()
+}
@@ -0,0 +1,434 @@
+package documents
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// OpenDocument namespaces.
+const (
+	odfText     = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odfTable    = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odfDraw     = "urn:oasis:names:tc:opendocument:xmlns:drawing:1.0"
+	odfStyle    = "urn:oasis:names:tc:opendocument:xmlns:style:1.0"
+	odfOffice   = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	odfManifest = "urn:oasis:names:tc:opendocument:xmlns:manifest:1.0"
+	xlinkNS     = "http://www.w3.org/1999/xlink"
+)
+
+type ODTExtractor struct{}
+
+func NewODTExtractor() *ODTExtractor {
+	return &ODTExtractor{}
+}
+
+// ExtractText reads an OpenDocument text CV, as saved by LibreOffice or
+// Google Docs, in reading order: headers, body, notes and footers, with
+// tables, text boxes and list numbers, followed by the targets of its links.
+func (e *ODTExtractor) ExtractText(ctx context.Context, file io.Reader) (string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("reading ODT file: %w", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("opening ODT package: %w", err)
+	}
+	// a password protected document lists how each part is encrypted
+	if manifest, err := readXMLPart(archive, "META-INF/manifest.xml"); err == nil && len(manifest.findAll(odfManifest, "encryption-data")) > 0 {
+		return "", fmt.Errorf("opening ODT package: %w", ErrEncryptedFile)
+	}
+	content, err := readXMLPart(archive, "content.xml")
+	if err != nil {
+		return "", fmt.Errorf("reading ODT content: %w", err)
+	}
+	// styles.xml holds the headers, footers and shared list styles; without
+	// it only those are lost
+	styles, err := readXMLPart(archive, "styles.xml")
+	if err != nil {
+		styles = &xmlNode{}
+	}
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("extracting ODT text: %w", err)
+	}
+
+	w := &odtText{listStyles: make(map[string][]odtListLevel)}
+	w.readListStyles(styles)
+	w.readListStyles(content)
+
+	var headers, footers []*xmlNode
+	for _, page := range styles.findAll(odfStyle, "master-page") {
+		for _, part := range page.children {
+			switch {
+			case part.is(odfStyle, "header"), part.is(odfStyle, "header-left"), part.is(odfStyle, "header-first"):
+				headers = append(headers, part)
+			case part.is(odfStyle, "footer"), part.is(odfStyle, "footer-left"), part.is(odfStyle, "footer-first"):
+				footers = append(footers, part)
+			}
+		}
+	}
+
+	var blocks [][]string
+	for _, header := range headers {
+		blocks = append(blocks, w.capture(header))
+	}
+	for _, body := range content.findAll(odfOffice, "text") {
+		blocks = append(blocks, w.capture(body))
+	}
+	blocks = append(blocks, w.notes)
+	for _, footer := range footers {
+		blocks = append(blocks, w.capture(footer))
+	}
+	return appendLinks(joinBlocks(blocks), w.links), nil
+}
+
+// odtListLevel is how one level of a list style labels its items.
+type odtListLevel struct {
+	// format is "" for bullets
+	format, prefix, suffix string
+	start                  int
+	// display is how many levels' numbers the label shows, e.g. 2 for "1.3"
+	display int
+}
+
+// odtText collects the text of an ODT in lines.
+type odtText struct {
+	lines []string
+	line  strings.Builder
+	space bool
+	links []link
+	notes []string
+
+	listStyles map[string][]odtListLevel
+	// lists are the open lists, innermost last, with their style and count
+	lists []odtList
+}
+
+type odtList struct {
+	style string
+	count int
+}
+
+// capture returns the lines of a part, kept apart from the others.
+func (w *odtText) capture(n *xmlNode) []string {
+	lines, line := w.lines, w.line.String()
+	w.lines, w.line = nil, strings.Builder{}
+	w.children(n)
+	w.breakLine()
+	captured := w.lines
+	w.lines = lines
+	w.line.WriteString(line)
+	return trimBlankLines(captured)
+}
+
+func (w *odtText) children(n *xmlNode) {
+	for _, c := range n.children {
+		w.node(c)
+	}
+}
+
+func (w *odtText) node(n *xmlNode) {
+	if n.name.Local == "" {
+		w.text(n.text)
+		return
+	}
+	switch {
+	case n.is(odfText, "p"), n.is(odfText, "h"):
+		w.breakLine()
+		w.children(n)
+		w.breakLine()
+	case n.is(odfText, "s"):
+		count := 1
+		if c, err := strconv.Atoi(n.attr(odfText, "c")); err == nil {
+			count = c
+		}
+		w.line.WriteString(strings.Repeat(" ", max(count, 0)))
+		w.space = false
+	case n.is(odfText, "tab"):
+		w.line.WriteString(" ")
+		w.space = false
+	case n.is(odfText, "line-break"):
+		w.breakLine()
+	case n.is(odfText, "a"):
+		start := w.line.Len()
+		lines := len(w.lines)
+		w.children(n)
+		if href := n.attr(xlinkNS, "href"); href != "" && lines == len(w.lines) {
+			w.links = append(w.links, link{text: w.line.String()[start:], url: href})
+		} else if href != "" {
+			w.links = append(w.links, link{url: href})
+		}
+	case n.is(odfText, "list"):
+		w.list(n)
+	case n.is(odfText, "note"):
+		w.note(n)
+	case n.is(odfTable, "table-row"):
+		w.row(n)
+	case n.is(odfDraw, "frame"):
+		// a frame holds a text box or an image; its title and description
+		// are alternative text, not content
+		for _, c := range n.children {
+			if c.is(odfDraw, "text-box") {
+				w.breakLine()
+				w.lines = append(w.lines, "")
+				w.children(c)
+				w.breakLine()
+				w.lines = append(w.lines, "")
+			}
+		}
+	case n.is(odfText, "tracked-changes"), n.is(odfText, "sequence-decls"),
+		n.is(odfText, "variable-decls"), n.is(odfText, "user-field-decls"),
+		n.is(odfOffice, "forms"), n.is(odfTable, "covered-table-cell"),
+		n.is(odfOffice, "annotation"), n.is(odfText, "soft-page-break"):
+	case n.is(odfTable, "table"), n.is(odfText, "section"):
+		w.breakLine()
+		w.lines = append(w.lines, "")
+		w.children(n)
+		w.lines = append(w.lines, "")
+	default:
+		w.children(n)
+	}
+}
+
+// list writes each item of a list on a line of its own, after its bullet or
+// number.
+func (w *odtText) list(n *xmlNode) {
+	style := n.attr(odfText, "style-name")
+	if style == "" && len(w.lists) > 0 {
+		style = w.lists[len(w.lists)-1].style
+	}
+	w.lists = append(w.lists, odtList{style: style})
+	defer func() { w.lists = w.lists[:len(w.lists)-1] }()
+
+	for _, item := range n.children {
+		if !item.is(odfText, "list-item") && !item.is(odfText, "list-header") {
+			continue
+		}
+		label := ""
+		if item.is(odfText, "list-item") {
+			w.lists[len(w.lists)-1].count++
+			label = w.label()
+		}
+		// the label goes before the item's first paragraph
+		for _, c := range item.children {
+			if (c.is(odfText, "p") || c.is(odfText, "h")) && label != "" {
+				w.breakLine()
+				w.line.WriteString(label + " ")
+				w.children(c)
+				w.breakLine()
+				label = ""
+				continue
+			}
+			w.node(c)
+		}
+	}
+}
+
+// label is the bullet or number of the current item of the innermost list.
+func (w *odtText) label() string {
+	depth := len(w.lists)
+	levels := w.listStyles[w.lists[depth-1].style]
+	if depth > len(levels) {
+		return "•"
+	}
+	level := levels[depth-1]
+	if level.format == "" {
+		return "•"
+	}
+	var numbers []string
+	for i := max(depth-level.display, 0); i < depth; i++ {
+		format, start := level.format, level.start
+		if i < depth-1 && levels[i].format != "" {
+			format, start = levels[i].format, levels[i].start
+		}
+		numbers = append(numbers, odtNumber(format, w.lists[i].count+start-1))
+	}
+	return level.prefix + strings.Join(numbers, ".") + level.suffix
+}
+
+// note writes a footnote's citation in place, e.g. "[1]", and its text
+// after the body.
+func (w *odtText) note(n *xmlNode) {
+	citation := ""
+	for _, c := range n.children {
+		if c.is(odfText, "note-citation") {
+			citation = strings.TrimSpace(c.innerText())
+		}
+	}
+	w.line.WriteString("[" + citation + "]")
+	w.space = false
+	for _, c := range n.children {
+		if c.is(odfText, "note-body") {
+			text := strings.Join(strings.Fields(strings.Join(w.capture(c), " ")), " ")
+			w.notes = append(w.notes, "["+citation+"] "+text)
+		}
+	}
+}
+
+// row writes a table row on one line, its cells joined by " | ".
+func (w *odtText) row(n *xmlNode) {
+	w.breakLine()
+	var cells []string
+	for _, c := range n.children {
+		if !c.is(odfTable, "table-cell") {
+			continue
+		}
+		if text := strings.Join(strings.Fields(strings.Join(w.capture(c), " ")), " "); text != "" {
+			cells = append(cells, text)
+		}
+	}
+	if len(cells) > 0 {
+		w.lines = append(w.lines, strings.Join(cells, " | "))
+	}
+}
+
+// text writes character data; white space collapses as in HTML.
+func (w *odtText) text(s string) {
+	for i, field := range strings.Fields(s) {
+		if (i > 0 || w.space || startsWithSpace(s)) && w.line.Len() > 0 && !strings.HasSuffix(w.line.String(), " ") {
+			w.line.WriteString(" ")
+		}
+		w.line.WriteString(field)
+		w.space = false
+	}
+	if endsWithSpace(s) {
+		w.space = true
+	}
+}
+
+func (w *odtText) breakLine() {
+	if w.line.Len() > 0 {
+		w.lines = append(w.lines, strings.TrimSpace(w.line.String()))
+	}
+	w.line.Reset()
+	w.space = false
+}
+
+// readListStyles records the bullet or number format of each level of the
+// list styles in a part.
+func (w *odtText) readListStyles(part *xmlNode) {
+	for _, style := range part.findAll(odfText, "list-style") {
+		var levels []odtListLevel
+		for _, lvl := range style.children {
+			level := odtListLevel{start: 1, display: 1}
+			switch {
+			case lvl.is(odfText, "list-level-style-bullet"), lvl.is(odfText, "list-level-style-image"):
+			case lvl.is(odfText, "list-level-style-number"):
+				level.format = lvl.attr(odfStyle, "num-format")
+				level.prefix = lvl.attr(odfStyle, "num-prefix")
+				level.suffix = lvl.attr(odfStyle, "num-suffix")
+				if start, err := strconv.Atoi(lvl.attr(odfText, "start-value")); err == nil {
+					level.start = start
+				}
+				if display, err := strconv.Atoi(lvl.attr(odfText, "display-levels")); err == nil {
+					level.display = display
+				}
+			default:
+				continue
+			}
+			levels = append(levels, level)
+		}
+		w.listStyles[style.attr(odfStyle, "name")] = levels
+	}
+}
+
+// odtNumber writes n in an ODF number format: "1", "a", "A", "i" or "I".
+func odtNumber(format string, n int) string {
+	switch format {
+	case "a":
+		return strings.ToLower(letters(n))
+	case "A":
+		return letters(n)
+	case "i":
+		return strings.ToLower(roman(n))
+	case "I":
+		return roman(n)
+	default:
+		return strconv.Itoa(n)
+	}
+}
+
+// xmlNode is an element of an XML document, or a run of character data
+// when it has no name, with its children in document order.
+type xmlNode struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	text     string
+	children []*xmlNode
+}
+
+// readXMLPart reads a part of a zip package into a tree.
+func readXMLPart(archive *zip.Reader, name string) (*xmlNode, error) {
+	file, err := archive.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	decoder := xml.NewDecoder(file)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return root, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: t.Name, attrs: t.Attr}
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			parent.children = append(parent.children, &xmlNode{text: string(t)})
+		}
+	}
+}
+
+func (n *xmlNode) is(space, local string) bool {
+	return n.name.Space == space && n.name.Local == local
+}
+
+func (n *xmlNode) attr(space, local string) string {
+	for _, a := range n.attrs {
+		if a.Name.Space == space && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// findAll returns the elements with a name below n, outermost first.
+func (n *xmlNode) findAll(space, local string) []*xmlNode {
+	var found []*xmlNode
+	for _, c := range n.children {
+		if c.is(space, local) {
+			found = append(found, c)
+			continue
+		}
+		found = append(found, c.findAll(space, local)...)
+	}
+	return found
+}
+
+func (n *xmlNode) innerText() string {
+	if n.name.Local == "" {
+		return n.text
+	}
+	var text strings.Builder
+	for _, c := range n.children {
+		text.WriteString(c.innerText())
+	}
+	return text.String()
+}
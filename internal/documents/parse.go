@@ -2,6 +2,7 @@ package documents
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"strings"
 
@@ -28,10 +29,10 @@ type CVSections struct {
 	Skills      []string
 }
 
-func (f *Formatter) ParseCV(fileData []byte) (*CVSections, error) {
+func (f *Formatter) ParseCV(ctx context.Context, fileData []byte) (*CVSections, error) {
 	reader := bytes.NewReader(fileData)
 
-	text, err := f.pdfProcessor.ExtractText(reader)
+	text, err := f.pdfProcessor.ExtractText(ctx, reader)
 		if err != nil {
 			return nil, fmt.Errorf("failed to extract text from PDF: %w", err)
 		}
@@ -83,7 +84,8 @@ func (f *Formatter) Format(sections *CVSections, jobDescription string) ([]byte,
 
 	pdf := gofpdf.New("P", "mm", "A4", "")
 	pdf.AddPage()
-	pdf.SetFont("Arial", "B", 16)
+	w := newPDFWriter(pdf)
+	w.SetFont("Arial", "B", 16)
 
 	// set margins
 	pdf.SetMargins(20, 20, 20)
@@ -91,13 +93,13 @@ func (f *Formatter) Format(sections *CVSections, jobDescription string) ([]byte,
 
 	// skills section
 	if len(sections.Skills) > 0 {
-		pdf.SetFont("Arial", "B", 14)
-		pdf.Cell(0, 10, "Skills")
+		w.SetFont("Arial", "B", 14)
+		w.Cell(0, 10, "Skills", 0, "L", "")
 		pdf.Ln(10)
 
-		pdf.SetFont("Arial", "", 12)
+		w.SetFont("Arial", "", 12)
 		for _, skill := range sections.Skills {
-			pdf.Cell(0, 6, skill)
+			w.Cell(0, 6, skill, 0, "L", "")
 			pdf.Ln(6)
 		}
 		pdf.Ln(5)
@@ -105,13 +107,13 @@ func (f *Formatter) Format(sections *CVSections, jobDescription string) ([]byte,
 
 	// experience section
 	if len(sections.Experiences) > 0 {
-		pdf.SetFont("Arial", "B", 14)
-		pdf.Cell(0, 10, "Experience")
+		w.SetFont("Arial", "B", 14)
+		w.Cell(0, 10, "Experience", 0, "L", "")
 		pdf.Ln(15)
 
-		pdf.SetFont("Arial", "", 12)
+		w.SetFont("Arial", "", 12)
 		for _, exp := range sections.Experiences {
-			pdf.Cell(0, 6, exp)
+			w.Cell(0, 6, exp, 0, "L", "")
 			pdf.Ln(8)
 		}
 		pdf.Ln(5)
@@ -119,13 +121,13 @@ func (f *Formatter) Format(sections *CVSections, jobDescription string) ([]byte,
 
 	// education section
 	if len(sections.Education) > 0 {
-		pdf.SetFont("Arial", "B", 14)
-		pdf.Cell(0, 10, "Education")
+		w.SetFont("Arial", "B", 14)
+		w.Cell(0, 10, "Education", 0, "L", "")
 		pdf.Ln(15)
 		
-		pdf.SetFont("Arial", "", 12)
+		w.SetFont("Arial", "", 12)
 		for _, edu := range sections.Education {
-			pdf.Cell(0, 6, edu)
+			w.Cell(0, 6, edu, 0, "L", "")
 			pdf.Ln(8)
 		}
 		pdf.Ln(5)
@@ -133,13 +135,13 @@ func (f *Formatter) Format(sections *CVSections, jobDescription string) ([]byte,
 
 	// projects section
 	if len(sections.Projects) > 0 {
-		pdf.SetFont("Arial", "B", 14)
-		pdf.Cell(0, 10, "Projects")
+		w.SetFont("Arial", "B", 14)
+		w.Cell(0, 10, "Projects", 0, "L", "")
 		pdf.Ln(15)
 		
-		pdf.SetFont("Arial", "", 12)
+		w.SetFont("Arial", "", 12)
 		for _, proj := range sections.Projects {
-			pdf.Cell(0, 6, proj)
+			w.Cell(0, 6, proj, 0, "L", "")
 			pdf.Ln(8)
 		}
 	}
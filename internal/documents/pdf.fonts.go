@@ -0,0 +1,292 @@
+package documents
+
+import (
+	"embed"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+//go:embed fonts/*.ttf
+var embeddedFonts embed.FS
+
+// fontFace is a TrueType family usable in generated PDFs. Styles map gofpdf
+// style strings ("", "B", "I", "BI") to TTF bytes.
+type fontFace struct {
+	family   string
+	styles   map[string][]byte
+	coverage *glyphCoverage
+}
+
+var (
+	fontsOnce    sync.Once
+	fontsMu      sync.RWMutex
+	unicodeFaces []*fontFace
+	fontsErr     error
+)
+
+// pdfFontChain returns the UTF-8 fonts tried, in order, for text the core
+// PDF fonts cannot encode: the bundled DejaVu Sans first, then any fallbacks
+// loaded with LoadFallbackFonts.
+func pdfFontChain() ([]*fontFace, error) {
+	fontsOnce.Do(func() {
+		face, err := loadEmbeddedDejaVu()
+		if err != nil {
+			fontsErr = err
+			return
+		}
+		fontsMu.Lock()
+		unicodeFaces = append([]*fontFace{face}, unicodeFaces...)
+		fontsMu.Unlock()
+	})
+	fontsMu.RLock()
+	defer fontsMu.RUnlock()
+	return append([]*fontFace(nil), unicodeFaces...), fontsErr
+}
+
+func loadEmbeddedDejaVu() (*fontFace, error) {
+	files := map[string]string{
+		"":   "fonts/DejaVuSansCondensed.ttf",
+		"B":  "fonts/DejaVuSansCondensed-Bold.ttf",
+		"I":  "fonts/DejaVuSansCondensed-Oblique.ttf",
+		"BI": "fonts/DejaVuSansCondensed-BoldOblique.ttf",
+	}
+	face := &fontFace{family: "DejaVuSans", styles: make(map[string][]byte)}
+	for style, name := range files {
+		data, err := embeddedFonts.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("reading embedded font %s: %w", name, err)
+		}
+		face.styles[style] = data
+	}
+	coverage, err := parseCoverage(face.styles[""])
+	if err != nil {
+		return nil, fmt.Errorf("reading DejaVu character map: %w", err)
+	}
+	face.coverage = coverage
+	return face, nil
+}
+
+// LoadFallbackFonts adds the TrueType fonts in dir to the end of the PDF
+// fallback chain, e.g. Noto Sans Arabic or a CJK font. Files are grouped by
+// family using the "<Family>-<Style>.ttf" naming convention; only the
+// Regular style is required.
+func LoadFallbackFonts(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.ttf"))
+	if err != nil {
+		return fmt.Errorf("listing fonts: %w", err)
+	}
+	sort.Strings(paths)
+
+	families := make(map[string]*fontFace)
+	var order []string
+	for _, path := range paths {
+		family, style := fontFileStyle(filepath.Base(path))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading font %s: %w", path, err)
+		}
+		face, ok := families[family]
+		if !ok {
+			face = &fontFace{family: family, styles: make(map[string][]byte)}
+			families[family] = face
+			order = append(order, family)
+		}
+		face.styles[style] = data
+	}
+
+	var faces []*fontFace
+	for _, family := range order {
+		face := families[family]
+		regular, ok := face.styles[""]
+		if !ok {
+			return fmt.Errorf("font family %s has no Regular style", family)
+		}
+		coverage, err := parseCoverage(regular)
+		if err != nil {
+			return fmt.Errorf("font family %s: %w", family, err)
+		}
+		face.coverage = coverage
+		faces = append(faces, face)
+	}
+
+	fontsMu.Lock()
+	unicodeFaces = append(unicodeFaces, faces...)
+	fontsMu.Unlock()
+	return nil
+}
+
+// fontFileStyle splits "NotoSansArabic-Bold.ttf" into family and gofpdf style.
+func fontFileStyle(name string) (family, style string) {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	family, suffix, _ := strings.Cut(name, "-")
+	switch strings.ToLower(suffix) {
+	case "bold":
+		style = "B"
+	case "italic", "oblique":
+		style = "I"
+	case "bolditalic", "boldoblique":
+		style = "BI"
+	}
+	return family, style
+}
+
+// coversCP1252 reports whether the gofpdf core fonts can draw text.
+func coversCP1252(text string) bool {
+	for _, r := range text {
+		if r == '\n' || r == '\t' {
+			continue
+		}
+		if _, ok := charmap.Windows1252.EncodeRune(r); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// glyphCoverage is the set of code points a font has glyphs for.
+type glyphCoverage struct {
+	ranges [][2]rune // sorted, inclusive
+}
+
+func (c *glyphCoverage) has(r rune) bool {
+	i := sort.Search(len(c.ranges), func(i int) bool { return c.ranges[i][1] >= r })
+	return i < len(c.ranges) && c.ranges[i][0] <= r
+}
+
+// parseCoverage reads the Unicode cmap of a TrueType font.
+func parseCoverage(ttf []byte) (*glyphCoverage, error) {
+	if len(ttf) < 12 {
+		return nil, fmt.Errorf("font file too short")
+	}
+	numTables := int(binary.BigEndian.Uint16(ttf[4:]))
+	var cmap []byte
+	for i := 0; i < numTables; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(ttf) {
+			return nil, fmt.Errorf("truncated table directory")
+		}
+		if string(ttf[rec:rec+4]) == "cmap" {
+			off := int(binary.BigEndian.Uint32(ttf[rec+8:]))
+			length := int(binary.BigEndian.Uint32(ttf[rec+12:]))
+			if off+length > len(ttf) {
+				return nil, fmt.Errorf("truncated cmap table")
+			}
+			cmap = ttf[off : off+length]
+			break
+		}
+	}
+	if len(cmap) < 4 {
+		return nil, fmt.Errorf("no cmap table")
+	}
+
+	// prefer a full-repertoire format 12 subtable, then BMP format 4
+	var format4, format12 []byte
+	n := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < n; i++ {
+		rec := 4 + 8*i
+		if rec+8 > len(cmap) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(cmap[rec:])
+		encoding := binary.BigEndian.Uint16(cmap[rec+2:])
+		off := int(binary.BigEndian.Uint32(cmap[rec+4:]))
+		if off+2 > len(cmap) {
+			continue
+		}
+		unicode := platform == 0 || (platform == 3 && (encoding == 1 || encoding == 10))
+		if !unicode {
+			continue
+		}
+		switch binary.BigEndian.Uint16(cmap[off:]) {
+		case 4:
+			format4 = cmap[off:]
+		case 12:
+			format12 = cmap[off:]
+		}
+	}
+
+	switch {
+	case format12 != nil:
+		return parseCmap12(format12)
+	case format4 != nil:
+		return parseCmap4(format4)
+	}
+	return nil, fmt.Errorf("no Unicode cmap subtable")
+}
+
+func parseCmap12(t []byte) (*glyphCoverage, error) {
+	if len(t) < 16 {
+		return nil, fmt.Errorf("truncated cmap format 12")
+	}
+	groups := int(binary.BigEndian.Uint32(t[12:]))
+	if 16+12*groups > len(t) {
+		return nil, fmt.Errorf("truncated cmap format 12")
+	}
+	c := &glyphCoverage{}
+	for i := 0; i < groups; i++ {
+		g := t[16+12*i:]
+		start := rune(binary.BigEndian.Uint32(g))
+		end := rune(binary.BigEndian.Uint32(g[4:]))
+		c.add(start, end)
+	}
+	return c, nil
+}
+
+func parseCmap4(t []byte) (*glyphCoverage, error) {
+	if len(t) < 14 {
+		return nil, fmt.Errorf("truncated cmap format 4")
+	}
+	segs := int(binary.BigEndian.Uint16(t[6:])) / 2
+	ends := 14
+	starts := ends + 2*segs + 2
+	deltas := starts + 2*segs
+	offsets := deltas + 2*segs
+	if offsets+2*segs > len(t) {
+		return nil, fmt.Errorf("truncated cmap format 4")
+	}
+
+	c := &glyphCoverage{}
+	for i := 0; i < segs; i++ {
+		end := int(binary.BigEndian.Uint16(t[ends+2*i:]))
+		start := int(binary.BigEndian.Uint16(t[starts+2*i:]))
+		delta := int(binary.BigEndian.Uint16(t[deltas+2*i:]))
+		rangeOffset := int(binary.BigEndian.Uint16(t[offsets+2*i:]))
+		for ch := start; ch <= end && ch != 0xFFFF; ch++ {
+			glyph := 0
+			if rangeOffset == 0 {
+				glyph = (ch + delta) & 0xFFFF
+			} else {
+				pos := offsets + 2*i + rangeOffset + 2*(ch-start)
+				if pos+2 > len(t) {
+					continue
+				}
+				if glyph = int(binary.BigEndian.Uint16(t[pos:])); glyph != 0 {
+					glyph = (glyph + delta) & 0xFFFF
+				}
+			}
+			if glyph != 0 {
+				c.add(rune(ch), rune(ch))
+			}
+		}
+	}
+	return c, nil
+}
+
+// add appends a range, merging it with the previous one when adjacent.
+// Subtables list code points in ascending order, so ranges stay sorted.
+func (c *glyphCoverage) add(start, end rune) {
+	if n := len(c.ranges); n > 0 && c.ranges[n-1][1]+1 >= start {
+		if end > c.ranges[n-1][1] {
+			c.ranges[n-1][1] = end
+		}
+		return
+	}
+	c.ranges = append(c.ranges, [2]rune{start, end})
+}
@@ -2,10 +2,14 @@ package documents
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 
 	// "os/exec"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/Emmanuella-codes/burnished-microservice/internal/utils"
@@ -19,7 +23,18 @@ func NewPDFProcessor() *PDFProcessor {
 	return &PDFProcessor{}
 }
 
-func (p *PDFProcessor) ExtractText(file io.Reader) (string, error) {
+// ExtractText extracts the text of each page in reading order, so the
+// columns of a multi-column CV come out one after the other, followed by
+// the targets of its web links.
+func (p *PDFProcessor) ExtractText(ctx context.Context, file io.Reader) (_ string, err error) {
+	// the object and page tree readers panic on a damaged file
+	defer func() {
+		if r := recover(); r != nil {
+			utils.LogError("ExtractText failed to read PDF", fmt.Errorf("%v", r))
+			err = fmt.Errorf("reading PDF: %v", r)
+		}
+	}()
+
 	fileBytes, err := io.ReadAll(file)
 	if err != nil {
 		utils.LogError("ExtractText failed to read file", err)
@@ -31,27 +46,85 @@ func (p *PDFProcessor) ExtractText(file io.Reader) (string, error) {
 	pdfReader, err := pdf.NewReader(reader, int64(len(fileBytes)))
 	if err != nil {
 		utils.LogError("ExtractText failed to parse PDF", err)
+		// a PDF that opens without a password is read; others fail here,
+		// with a password or with encryption the reader does not support
+		if errors.Is(err, pdf.ErrInvalidPassword) || hasEncryptEntry(fileBytes) {
+			return "", fmt.Errorf("parsing PDF: %w: %w", ErrEncryptedFile, err)
+		}
 		return "", fmt.Errorf("parsing PDF: %w", err)
 	}
 
 	var allText string
+	var links []link
 	numPages := pdfReader.NumPage()
 
 	for i := 1; i <= numPages; i++ {
+		if err := ctx.Err(); err != nil {
+			return "", fmt.Errorf("extracting text from page %d: %w", i, err)
+		}
 		page := pdfReader.Page(i)
 		if page.V.IsNull() {
 			continue
 		}
 
-		text, err := page.GetPlainText(nil)
-		if err != nil {
-			return "", fmt.Errorf("error extracting text from page %d: %w", i, err)
+		// read columns in order; fall back to the content stream's order
+		// when the layout cannot be read
+		text, err := layoutText(page)
+		if err != nil || strings.TrimSpace(text) == "" {
+			if err != nil {
+				utils.LogWarn("Falling back to plain PDF text", "page", i, "error", err)
+			}
+			text, err = page.GetPlainText(nil)
+			if err != nil {
+				return "", fmt.Errorf("error extracting text from page %d: %w", i, err)
+			}
 		}
 
 		allText += text + "\n"
+
+		found, err := pageLinks(page)
+		if err != nil {
+			utils.LogWarn("Skipping PDF links", "page", i, "error", err)
+		}
+		links = append(links, found...)
 	}
 
-	return allText, nil
+	return appendLinks(allText, links), nil
+}
+
+// encryptEntry matches the /Encrypt key but not, say, /EncryptMetadata.
+var encryptEntry = regexp.MustCompile(`/Encrypt\b`)
+
+// hasEncryptEntry reports whether the trailer that startxref points to, or
+// the cross-reference stream dictionary standing in for it, has an /Encrypt
+// entry. Every trailer of an encrypted file repeats it.
+func hasEncryptEntry(data []byte) bool {
+	at := bytes.LastIndex(data, []byte("startxref"))
+	if at < 0 {
+		return false
+	}
+	fields := bytes.Fields(data[at+len("startxref"):])
+	if len(fields) == 0 {
+		return false
+	}
+	offset, err := strconv.Atoi(string(fields[0]))
+	if err != nil || offset < 0 || offset >= at {
+		return false
+	}
+
+	section := data[offset:at]
+	if bytes.HasPrefix(bytes.TrimSpace(section), []byte("xref")) {
+		// a table, followed by the trailer dictionary
+		if i := bytes.Index(section, []byte("trailer")); i >= 0 {
+			return encryptEntry.Match(section[i:])
+		}
+		return false
+	}
+	// a stream, whose dictionary comes before its data
+	if i := bytes.Index(section, []byte("stream")); i >= 0 {
+		section = section[:i]
+	}
+	return encryptEntry.Match(section)
 }
 
 func (p *PDFProcessor) CreateFormattedDocument(content string) ([]byte, error) {
@@ -62,8 +135,9 @@ func (p *PDFProcessor) CreateFormattedDocument(content string) ([]byte, error) {
 	pdf.SetMargins(20, 20, 20)
 	pdf.SetAutoPageBreak(true, 20)
 
-	// set default font
-	pdf.SetFont("Arial", "", 11)
+	// set default font; the writer falls back to embedded UTF-8 fonts
+	w := newPDFWriter(pdf)
+	w.SetFont("Arial", "", 11)
 
 	// split content into paragraphs
 	paragraphs := strings.Split(content, "\n\n")
@@ -84,7 +158,7 @@ func (p *PDFProcessor) CreateFormattedDocument(content string) ([]byte, error) {
 			}
 
 			// multicell for automatic text wrapping
-			pdf.MultiCell(0, 6, line, "", "", false)
+			w.MultiCell(0, 6, line, "L")
 			pdf.Ln(2) // small space between lines
 		}
 
@@ -0,0 +1,380 @@
+package documents
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Layout thresholds, as fractions of the font size unless noted.
+const (
+	// glyphs whose baselines are this close share a line
+	lineTolerance = 0.3
+	// a gap wider than this between glyphs is a space between words
+	wordGap = 0.3
+	// a gap wider than this starts a new span, e.g. the next column
+	spanGap = 1.2
+	// a vertical gap this much wider than a line starts a new paragraph,
+	// or this much wider than the usual step between lines of the column
+	paragraphGap  = 1.8
+	paragraphStep = 1.4
+
+	// a gutter is at least this wide, in points
+	minGutterWidth = 8.0
+	// at most this share of lines may cross a gutter, e.g. a centred name
+	maxGutterCrossing = 0.15
+	// each side of a gutter needs text on at least this many lines
+	minColumnLines = 3
+	// the sparser side of a gutter needs at least this share of the other
+	// side's lines, and this share of its own lines with nothing across the
+	// gutter; right-aligned dates next to job titles have neither
+	minColumnBalance = 0.2
+	minColumnSolo    = 0.25
+
+	// columns are only looked for in text at most this wide, in points; a
+	// glyph far off the page, as in a damaged or crafted file, would
+	// otherwise size the coverage counts below by its position
+	maxLayoutWidth = 2000.0
+)
+
+// glyph is one positioned character from a page's content stream.
+type glyph struct {
+	x, y, w, size float64
+	s             string
+	// runX is where the string the glyph belongs to starts, so estimated
+	// positions that overshoot into the next string keep their order
+	runX float64
+}
+
+// pdfSpan is text on one line with no wide gap inside it.
+type pdfSpan struct {
+	x0, x1  float64
+	y, size float64
+	text    string
+}
+
+// pdfLine is the spans sharing a baseline, left to right.
+type pdfLine struct {
+	y, size float64
+	spans   []pdfSpan
+}
+
+// layoutText recovers the reading order of a page from its positioned
+// glyphs: glyphs are grouped into lines and spans, columns are found from
+// the vertical gutters no text crosses, and each band of columns is read
+// column by column, top to bottom. Lines crossing a gutter, such as a
+// heading across the page, are read where they stand.
+func layoutText(page pdf.Page) (text string, err error) {
+	// the content stream parser panics on malformed operators
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("reading page layout: %v", r)
+		}
+	}()
+
+	lines := groupLines(pageGlyphs(page.Content().Text))
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return readingOrder(lines, findGutters(lines)), nil
+}
+
+// pageGlyphs positions each character. A font without a width table, such
+// as one of the standard 14, leaves every character of a string at the
+// string's start, so those are laid out from estimated widths instead.
+func pageGlyphs(texts []pdf.Text) []glyph {
+	var glyphs []glyph
+	var previous pdf.Text
+	for _, t := range texts {
+		if t.S == "" || t.FontSize <= 0 {
+			continue
+		}
+		g := glyph{x: t.X, y: t.Y, w: glyphWidth(t), size: t.FontSize, s: t.S, runX: t.X}
+		if n := len(glyphs); n > 0 && t.W == 0 && t.X == previous.X && t.Y == previous.Y {
+			g.x = glyphs[n-1].x + glyphs[n-1].w
+		}
+		glyphs = append(glyphs, g)
+		previous = t
+	}
+	return glyphs
+}
+
+// glyphWidth is the glyph's advance, estimated from its font size when the
+// font has no width table.
+func glyphWidth(t pdf.Text) float64 {
+	if t.W > 0 {
+		return t.W
+	}
+	r := []rune(t.S)[0]
+	switch {
+	case unicode.IsSpace(r), unicode.IsPunct(r):
+		return 0.28 * t.FontSize
+	case unicode.IsUpper(r):
+		return 0.7 * t.FontSize
+	default:
+		return 0.55 * t.FontSize
+	}
+}
+
+// groupLines clusters glyphs by baseline, top of the page first, and splits
+// each line into spans at wide gaps.
+func groupLines(glyphs []glyph) []pdfLine {
+	sort.SliceStable(glyphs, func(i, j int) bool { return glyphs[i].y > glyphs[j].y })
+
+	var lines []pdfLine
+	for start := 0; start < len(glyphs); {
+		end := start + 1
+		size := glyphs[start].size
+		for end < len(glyphs) && glyphs[start].y-glyphs[end].y <= lineTolerance*max(size, glyphs[end].size) {
+			size = max(size, glyphs[end].size)
+			end++
+		}
+		line := glyphs[start:end]
+		sort.SliceStable(line, func(i, j int) bool { return line[i].runX < line[j].runX })
+		lines = append(lines, pdfLine{y: glyphs[start].y, size: size, spans: splitSpans(line)})
+		start = end
+	}
+	return lines
+}
+
+func splitSpans(line []glyph) []pdfSpan {
+	var spans []pdfSpan
+	var text strings.Builder
+	var current pdfSpan
+	space := false
+	flush := func() {
+		if s := strings.TrimSpace(text.String()); s != "" {
+			current.text = s
+			spans = append(spans, current)
+		}
+		text.Reset()
+	}
+
+	for i, g := range line {
+		blank := strings.TrimSpace(g.s) == ""
+		if i > 0 {
+			gap := g.x - current.x1
+			if gap > spanGap*g.size && !blank {
+				flush()
+				current = pdfSpan{x0: g.x, y: g.y}
+				space = false
+			} else if gap > wordGap*g.size {
+				space = true
+			}
+		} else {
+			current.x0, current.y = g.x, g.y
+		}
+		current.x1 = max(current.x1, g.x+g.w)
+		current.size = max(current.size, g.size)
+
+		if blank {
+			space = true
+			continue
+		}
+		if space && text.Len() > 0 {
+			text.WriteByte(' ')
+		}
+		space = false
+		text.WriteString(g.s)
+	}
+	flush()
+	return spans
+}
+
+// findGutters returns the x positions of the gaps between columns: vertical
+// strips at least minGutterWidth wide that few lines cross and that have
+// text on both sides.
+func findGutters(lines []pdfLine) []float64 {
+	if len(lines) < 2*minColumnLines {
+		return nil
+	}
+	left, right := math.Inf(1), math.Inf(-1)
+	for _, line := range lines {
+		for _, span := range line.spans {
+			left, right = min(left, span.x0), max(right, span.x1)
+		}
+	}
+
+	// count the lines covering each point-wide strip across the page
+	if extent := right - left; !(extent > 0 && extent <= maxLayoutWidth) {
+		return nil
+	}
+	width := int(math.Ceil(right - left))
+	coverage := make([]int, width)
+	covered := make([]bool, width)
+	for _, line := range lines {
+		clear(covered)
+		for _, span := range line.spans {
+			for x := int(span.x0 - left); x < int(math.Ceil(span.x1-left)) && x < width; x++ {
+				covered[max(x, 0)] = true
+			}
+		}
+		for x, c := range covered {
+			if c {
+				coverage[x]++
+			}
+		}
+	}
+
+	allowed := int(maxGutterCrossing * float64(len(lines)))
+	var gutters []float64
+	for x := 0; x < width; {
+		if coverage[x] > allowed {
+			x++
+			continue
+		}
+		start := x
+		for x < width && coverage[x] <= allowed {
+			x++
+		}
+		// a strip at the page edge is margin, not a gutter
+		if start == 0 || x == width || float64(x-start) < minGutterWidth {
+			continue
+		}
+		if gutter := left + float64(start+x)/2; isColumnGutter(lines, gutter) {
+			gutters = append(gutters, gutter)
+		}
+	}
+	return gutters
+}
+
+// isColumnGutter reports whether the text either side of x reads as two
+// columns rather than, say, titles with their dates aligned right.
+func isColumnGutter(lines []pdfLine, x float64) bool {
+	var left, right, leftOnly, rightOnly int
+	for _, line := range lines {
+		hasLeft, hasRight := false, false
+		for _, span := range line.spans {
+			hasLeft = hasLeft || span.x1 <= x
+			hasRight = hasRight || span.x0 >= x
+		}
+		switch {
+		case hasLeft && hasRight:
+			left++
+			right++
+		case hasLeft:
+			left++
+			leftOnly++
+		case hasRight:
+			right++
+			rightOnly++
+		}
+	}
+
+	sparse, solo := right, rightOnly
+	if left < right {
+		sparse, solo = left, leftOnly
+	}
+	return sparse >= minColumnLines &&
+		float64(sparse) >= minColumnBalance*float64(max(left, right)) &&
+		float64(solo) >= minColumnSolo*float64(sparse)
+}
+
+// columnLine is the part of a line that falls in one column.
+type columnLine struct {
+	y, size float64
+	text    string
+}
+
+// readingOrder writes the lines out, reading each band between lines that
+// cross a gutter column by column.
+func readingOrder(lines []pdfLine, gutters []float64) string {
+	var out []string
+	var last *columnLine
+	emit := func(block []columnLine) {
+		step := lineStep(block)
+		for i := range block {
+			line := &block[i]
+			if last != nil {
+				gap := last.y - line.y
+				limit := paragraphGap * max(line.size, last.size)
+				if step > 0 {
+					limit = max(paragraphStep*step, max(line.size, last.size)*1.2)
+				}
+				// a new paragraph, or a jump back up to the next column
+				if gap < 0 || gap > limit {
+					out = append(out, "")
+				}
+			}
+			out = append(out, line.text)
+			last = line
+		}
+	}
+
+	columns := make([][]columnLine, len(gutters)+1)
+	flush := func() {
+		for i, column := range columns {
+			emit(column)
+			columns[i] = nil
+		}
+	}
+
+	var pending []columnLine
+	for _, line := range lines {
+		if crossesGutter(line, gutters) {
+			flush()
+			pending = append(pending, columnLine{line.y, line.size, joinSpans(line.spans)})
+			continue
+		}
+		if len(pending) > 0 {
+			emit(pending)
+			pending = nil
+		}
+		byColumn := make([][]pdfSpan, len(columns))
+		for _, span := range line.spans {
+			column := sort.SearchFloat64s(gutters, span.x0)
+			byColumn[column] = append(byColumn[column], span)
+		}
+		for column, spans := range byColumn {
+			if len(spans) > 0 {
+				columns[column] = append(columns[column], columnLine{spans[0].y, spans[0].size, joinSpans(spans)})
+			}
+		}
+	}
+	emit(pending)
+	flush()
+
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// lineStep is the usual distance between consecutive lines of block, or
+// zero when it has too few lines to tell.
+func lineStep(block []columnLine) float64 {
+	var steps []float64
+	for i := 1; i < len(block); i++ {
+		if step := block[i-1].y - block[i].y; step > 0 {
+			steps = append(steps, step)
+		}
+	}
+	if len(steps) < 3 {
+		return 0
+	}
+	sort.Float64s(steps)
+	return steps[len(steps)/2]
+}
+
+func crossesGutter(line pdfLine, gutters []float64) bool {
+	for _, span := range line.spans {
+		for _, gutter := range gutters {
+			if span.x0 < gutter && span.x1 > gutter {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func joinSpans(spans []pdfSpan) string {
+	texts := make([]string, len(spans))
+	for i, span := range spans {
+		texts[i] = span.text
+	}
+	return strings.Join(texts, " ")
+}
@@ -0,0 +1,59 @@
+package documents
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// twoColumns lays out rows of a sidebar at x 40-150 and a main column at
+// x 200-550, one line every 14pt. Some rows have text in only one column,
+// as real columns do.
+func twoColumns(rows int) []pdfLine {
+	var lines []pdfLine
+	for i := 0; i < rows; i++ {
+		y := 750 - 14*float64(i)
+		line := pdfLine{y: y, size: 10}
+		if i%3 != 2 {
+			line.spans = append(line.spans, pdfSpan{x0: 40, x1: 150, y: y, size: 10, text: "sidebar"})
+		}
+		if i%3 != 1 {
+			line.spans = append(line.spans, pdfSpan{x0: 200, x1: 550, y: y, size: 10, text: "main"})
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestFindGutters(t *testing.T) {
+	if got, want := findGutters(twoColumns(9)), []float64{175}; !reflect.DeepEqual(got, want) {
+		t.Errorf("findGutters = %v, want %v", got, want)
+	}
+}
+
+func TestFindGuttersOffPage(t *testing.T) {
+	tests := []struct {
+		name string
+		x    float64
+	}{
+		{"far right", 1e9},
+		{"far left", -1e9},
+		{"infinite", math.Inf(1)},
+		{"not a number", math.NaN()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := twoColumns(9)
+			lines[3].spans = append(lines[3].spans, pdfSpan{x0: tt.x, x1: tt.x + 5, y: lines[3].y, size: 10, text: "x"})
+
+			var gutters []float64
+			allocs := testing.AllocsPerRun(1, func() { gutters = findGutters(lines) })
+			if gutters != nil {
+				t.Errorf("findGutters = %v, want none for text this wide", gutters)
+			}
+			if allocs > 0 {
+				t.Errorf("findGutters made %v allocations, want none", allocs)
+			}
+		})
+	}
+}
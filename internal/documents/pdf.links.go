@@ -0,0 +1,73 @@
+package documents
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// linkMargin widens a link's rectangle, in points, so glyphs whose boxes
+// only touch its edge still count as its text.
+const linkMargin = 2.0
+
+// pageLinks reads the URI link annotations on a page. Each link's text is
+// what is printed inside its rectangle, read in lines.
+func pageLinks(page pdf.Page) (links []link, err error) {
+	// the content stream parser panics on malformed operators
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("reading page links: %v", r)
+		}
+	}()
+
+	annots := page.V.Key("Annots")
+	var glyphs []glyph
+	for i := 0; i < annots.Len(); i++ {
+		annot := annots.Index(i)
+		if annot.Key("Subtype").Name() != "Link" {
+			continue
+		}
+		action := annot.Key("A")
+		if action.Key("S").Name() != "URI" {
+			continue
+		}
+		address := strings.TrimSpace(action.Key("URI").RawString())
+		if address == "" {
+			continue
+		}
+
+		// only lay out the page once it has a link worth labelling
+		if glyphs == nil {
+			glyphs = pageGlyphs(page.Content().Text)
+		}
+		links = append(links, link{text: linkText(glyphs, annot.Key("Rect")), url: address})
+	}
+	return links, nil
+}
+
+// linkText joins the glyphs inside rect, a PDF rectangle [x0 y0 x1 y1].
+// Glyphs are placed by the start of their string, which is exact even where
+// their own positions are estimated.
+func linkText(glyphs []glyph, rect pdf.Value) string {
+	if rect.Len() != 4 {
+		return ""
+	}
+	x0, y0 := rect.Index(0).Float64(), rect.Index(1).Float64()
+	x1, y1 := rect.Index(2).Float64(), rect.Index(3).Float64()
+	x0, x1 = min(x0, x1)-linkMargin, max(x0, x1)+linkMargin
+	y0, y1 = min(y0, y1)-linkMargin, max(y0, y1)+linkMargin
+
+	var inside []glyph
+	for _, g := range glyphs {
+		if mid := g.runX + g.w/2; mid >= x0 && mid <= x1 && g.y >= y0 && g.y <= y1 {
+			inside = append(inside, g)
+		}
+	}
+
+	var texts []string
+	for _, line := range groupLines(inside) {
+		texts = append(texts, joinSpans(line.spans))
+	}
+	return strings.Join(texts, " ")
+}
@@ -0,0 +1,304 @@
+package documents
+
+import (
+	"strings"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/utils"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// pdfWriter draws text with the requested core font when it can encode the
+// text, and otherwise falls back through the embedded UTF-8 fonts. All PDF
+// output goes through it so every document handles non-Latin names and
+// right-to-left scripts the same way.
+type pdfWriter struct {
+	pdf        *gofpdf.Fpdf
+	tr         func(string) string
+	chain      []*fontFace
+	registered map[string]bool
+
+	family string
+	style  string
+	size   float64
+}
+
+func newPDFWriter(pdf *gofpdf.Fpdf) *pdfWriter {
+	chain, err := pdfFontChain()
+	if err != nil {
+		// core fonts still work, so degrade rather than fail the document
+		utils.LogError("Unicode PDF fonts unavailable", err)
+	}
+	return &pdfWriter{
+		pdf:        pdf,
+		tr:         pdf.UnicodeTranslatorFromDescriptor(""),
+		chain:      chain,
+		registered: make(map[string]bool),
+		family:     "Helvetica",
+	}
+}
+
+// SetFont selects the preferred core font; it only takes effect on the PDF
+// when text is drawn, because the face actually used depends on the text.
+func (w *pdfWriter) SetFont(family, style string, size float64) {
+	w.family, w.style, w.size = family, style, size
+	w.pdf.SetFont(family, style, size)
+}
+
+// fontRun is a stretch of text drawn in one face; a nil face is the
+// requested core font.
+type fontRun struct {
+	face *fontFace
+	text string
+}
+
+// prepare shapes Arabic in text, which must happen before it is wrapped or
+// reordered.
+func (w *pdfWriter) prepare(text string) string {
+	if len(w.chain) > 0 && !coversCP1252(text) && hasRTL(text) {
+		return shapeArabic(text)
+	}
+	return text
+}
+
+// runs splits text by the face that draws each rune. Text the core fonts can
+// encode is one run in the requested font. Otherwise a run keeps its face
+// while that covers the next rune, so spaces and punctuation do not break it
+// up, and a rune it lacks starts a run in the first face of the chain that
+// has it. A rune no face has stays in the current run.
+func (w *pdfWriter) runs(text string) []fontRun {
+	if coversCP1252(text) || len(w.chain) == 0 {
+		return []fontRun{{text: text}}
+	}
+	var runs []fontRun
+	var face *fontFace
+	start := 0
+	for i, r := range text {
+		next := face
+		if next == nil || (r != ' ' && r != '\n' && r != '\t' && !next.coverage.has(r)) {
+			next = w.faceFor(r, face)
+		}
+		if next != face && face != nil {
+			runs = append(runs, fontRun{face: face, text: text[start:i]})
+			start = i
+		}
+		face = next
+	}
+	return append(runs, fontRun{face: face, text: text[start:]})
+}
+
+// faceFor returns the first font in the chain that can draw r, else current,
+// else the first font in the chain.
+func (w *pdfWriter) faceFor(r rune, current *fontFace) *fontFace {
+	for _, face := range w.chain {
+		if face.coverage.has(r) {
+			return face
+		}
+	}
+	if current != nil {
+		return current
+	}
+	return w.chain[0]
+}
+
+// use activates the font of run and returns its text as gofpdf expects it:
+// cp1252-translated for the core fonts.
+func (w *pdfWriter) use(run fontRun) string {
+	if run.face == nil {
+		w.pdf.SetFont(w.family, w.style, w.size)
+		return w.tr(run.text)
+	}
+	w.register(run.face)
+	w.pdf.SetFont(run.face.family, w.style, w.size)
+	return run.text
+}
+
+// width measures prepared text run by run.
+func (w *pdfWriter) width(text string) float64 {
+	total := 0.0
+	for _, run := range w.runs(text) {
+		total += w.pdf.GetStringWidth(w.use(run))
+	}
+	return total
+}
+
+// cell draws prepared, visually ordered text like gofpdf's CellFormat, one
+// cell per run when it takes more than one face.
+func (w *pdfWriter) cell(width, h float64, text string, ln int, align, link string) {
+	runs := w.runs(text)
+	if len(runs) == 1 {
+		w.pdf.CellFormat(width, h, w.use(runs[0]), "", ln, align, false, 0, link)
+		return
+	}
+
+	left, _, right, _ := w.pdf.GetMargins()
+	x, y := w.pdf.GetXY()
+	if width == 0 {
+		pageW, _ := w.pdf.GetPageSize()
+		width = pageW - right - x
+	}
+	margin := w.pdf.GetCellMargin()
+	total := w.width(text)
+	start := x + margin
+	switch {
+	case strings.Contains(align, "R"):
+		start = x + width - margin - total
+	case strings.Contains(align, "C"):
+		start = x + (width-total)/2
+	}
+
+	// the runs sit side by side, so only the cell as a whole has margins
+	w.pdf.SetCellMargin(0)
+	for _, run := range runs {
+		drawn := w.use(run)
+		runWidth := w.pdf.GetStringWidth(drawn)
+		w.pdf.SetXY(start, y)
+		w.pdf.CellFormat(runWidth, h, drawn, "", 0, "L", false, 0, link)
+		start += runWidth
+	}
+	w.pdf.SetCellMargin(margin)
+
+	switch ln {
+	case 1:
+		w.pdf.SetXY(left, y+h)
+	case 2:
+		w.pdf.SetXY(x, y+h)
+	default:
+		w.pdf.SetXY(x+width, y)
+	}
+}
+
+// register adds all styles of face to the document the first time it is
+// used, reusing the regular outline for styles the family does not ship.
+func (w *pdfWriter) register(face *fontFace) {
+	if w.registered[face.family] {
+		return
+	}
+	w.registered[face.family] = true
+	for _, style := range []string{"", "B", "I", "BI"} {
+		data, ok := face.styles[style]
+		if !ok && style == "BI" {
+			data, ok = face.styles["B"]
+		}
+		if !ok {
+			data = face.styles[""]
+		}
+		w.pdf.AddUTF8FontFromBytes(face.family, style, data)
+	}
+}
+
+// Width returns the rendered width of text in the current font settings.
+func (w *pdfWriter) Width(text string) float64 {
+	return w.width(w.prepare(text))
+}
+
+// Cell draws a single line of text like gofpdf's CellFormat.
+func (w *pdfWriter) Cell(width, h float64, text string, ln int, align, link string) {
+	drawn := w.prepare(text)
+	if hasRTL(drawn) {
+		rtl := rtlBase(drawn)
+		drawn = visualOrder(drawn, rtl)
+		if rtl && align == "L" {
+			align = "R"
+		}
+	}
+	w.cell(width, h, drawn, ln, align, link)
+}
+
+// MultiCell draws text wrapped to width (0 means up to the right margin),
+// starting at the current x. Right-to-left paragraphs are wrapped in logical
+// order first and each line is then reordered, so lines read top to bottom.
+func (w *pdfWriter) MultiCell(width, h float64, text, align string) {
+	left, _, right, _ := w.pdf.GetMargins()
+	pageW, _ := w.pdf.GetPageSize()
+	x := w.pdf.GetX()
+	if width == 0 {
+		width = pageW - right - x
+	}
+
+	for _, paragraph := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		drawn := w.prepare(paragraph)
+		rtl := hasRTL(drawn) && rtlBase(drawn)
+		lineAlign := align
+		if rtl && (align == "L" || align == "") {
+			lineAlign = "R"
+		}
+
+		lines := w.wrap(drawn, width-2*w.pdf.GetCellMargin())
+		if len(lines) == 0 {
+			lines = []string{""}
+		}
+		for _, line := range lines {
+			if hasRTL(line) {
+				line = visualOrder(line, rtl)
+			}
+			w.pdf.SetX(x)
+			w.cell(width, h, line, 2, lineAlign, "")
+		}
+	}
+	w.pdf.SetX(left)
+}
+
+// Write flows text inline after the current position, like gofpdf's Write.
+// Right-to-left text cannot flow inline, so it is drawn as a paragraph.
+func (w *pdfWriter) Write(h float64, text string) {
+	drawn := w.prepare(text)
+	if hasRTL(drawn) {
+		w.MultiCell(0, h, text, "L")
+		return
+	}
+	for _, run := range w.runs(drawn) {
+		w.pdf.Write(h, w.use(run))
+	}
+}
+
+// wrap breaks prepared text into lines no wider than width, splitting at
+// spaces and breaking words that are longer than a whole line.
+func (w *pdfWriter) wrap(text string, width float64) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if w.width(candidate) <= width {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+			line = ""
+		}
+		for w.width(word) > width {
+			cut := w.fit(word, width)
+			lines = append(lines, word[:cut])
+			word = word[cut:]
+		}
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fit returns the byte length of the longest prefix of word that fits.
+func (w *pdfWriter) fit(word string, width float64) int {
+	cut := 0
+	for i := range word {
+		if i > 0 && w.width(word[:i]) > width {
+			break
+		}
+		cut = i
+	}
+	if cut == 0 {
+		// always make progress, even if a single glyph is too wide
+		for i := range word {
+			if i > 0 {
+				return i
+			}
+		}
+		return len(word)
+	}
+	return cut
+}
@@ -0,0 +1,101 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/utils"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// scriptFaces returns two faces sharing the DejaVu outlines but told apart
+// by coverage: one has Latin only, the other Greek only.
+func scriptFaces(t *testing.T) (latin, greek *fontFace) {
+	t.Helper()
+	dejaVu, err := loadEmbeddedDejaVu()
+	if err != nil {
+		t.Fatal(err)
+	}
+	latin = &fontFace{family: "Latin", styles: dejaVu.styles, coverage: &glyphCoverage{ranges: [][2]rune{{0x20, 0x24f}}}}
+	greek = &fontFace{family: "Greek", styles: dejaVu.styles, coverage: &glyphCoverage{ranges: [][2]rune{{0x370, 0x3ff}}}}
+	return latin, greek
+}
+
+func TestPDFWriterRuns(t *testing.T) {
+	latin, greek := scriptFaces(t)
+	w := &pdfWriter{chain: []*fontFace{latin, greek}}
+	family := func(face *fontFace) string {
+		if face == nil {
+			return "core"
+		}
+		return face.family
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want []fontRun
+	}{
+		{"core font", "Ada Okafor", []fontRun{{nil, "Ada Okafor"}}},
+		{"one face", "Łódź", []fontRun{{latin, "Łódź"}}},
+		{"mixed", "Ada Ωμέγα Okafor", []fontRun{{latin, "Ada "}, {greek, "Ωμέγα "}, {latin, "Okafor"}}},
+		{"punctuation the face lacks", "Ωμέγα, Ada", []fontRun{{greek, "Ωμέγα"}, {latin, ", Ada"}}},
+		{"starts with the fallback", "Ωμέγα Ada", []fontRun{{greek, "Ωμέγα "}, {latin, "Ada"}}},
+		{"no face has it", "Ada 李", []fontRun{{latin, "Ada 李"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := w.runs(tt.text)
+			if len(got) != len(tt.want) {
+				t.Fatalf("runs = %d, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("run %d = %q in %s, want %q in %s", i, got[i].text, family(got[i].face), tt.want[i].text, family(tt.want[i].face))
+				}
+			}
+		})
+	}
+}
+
+func TestPDFWriterMixedScripts(t *testing.T) {
+	utils.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	latin, greek := scriptFaces(t)
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	w := newPDFWriter(pdf)
+	w.chain = []*fontFace{latin, greek}
+	w.SetFont("Helvetica", "", 12)
+
+	line := "Ada Ωμέγα Okafor"
+	w.Cell(0, 6, line, 1, "L", "")
+	w.MultiCell(60, 6, "Σύνοψη: backend engineer στο Lagos", "L")
+
+	for _, face := range w.chain {
+		if !w.registered[face.family] {
+			t.Errorf("%s face not used", face.family)
+		}
+	}
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatalf("writing PDF: %v", err)
+	}
+	text, err := NewPDFProcessor().ExtractText(context.Background(), bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	// the reader does not map the subset fonts' glyphs back to Greek, so
+	// only the Latin runs and their order along the line can be checked
+	lines := strings.Split(text, "\n")
+	if first := strings.Fields(lines[0]); len(first) != 3 || first[0] != "Ada" || first[2] != "Okafor" {
+		t.Errorf("first line = %q, want the three words of %q on one line", lines[0], line)
+	}
+	if !strings.Contains(text, "backend engineer") {
+		t.Errorf("text = %q, want the wrapped paragraph", text)
+	}
+}
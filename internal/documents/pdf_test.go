@@ -0,0 +1,105 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/utils"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// samplePDF writes a one-page PDF, encrypted to open with password unless
+// that is empty.
+func samplePDF(t *testing.T, password string) []byte {
+	t.Helper()
+	doc := gofpdf.New("P", "mm", "A4", "")
+	if password != "" {
+		doc.SetProtection(gofpdf.CnProtectPrint, password, "owner")
+	}
+	doc.AddPage()
+	doc.SetFont("Helvetica", "", 12)
+	doc.Cell(0, 10, "Ada Okafor")
+	var buf bytes.Buffer
+	if err := doc.Output(&buf); err != nil {
+		t.Fatalf("writing PDF: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestPDFExtractTextEncrypted(t *testing.T) {
+	utils.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	locked := samplePDF(t, "secret")
+	// a standard security handler version the reader does not implement
+	unsupported := bytes.Replace(locked, []byte("/V 1\n/R 2"), []byte("/V 5\n/R 6"), 1)
+	if bytes.Equal(unsupported, locked) {
+		t.Fatal("encryption dictionary not found in the sample")
+	}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		encrypted bool
+	}{
+		{"plain", samplePDF(t, ""), false},
+		{"user password", locked, true},
+		{"unsupported encryption", unsupported, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := NewPDFProcessor().ExtractText(context.Background(), bytes.NewReader(tt.data))
+			if tt.encrypted {
+				if !errors.Is(err, ErrEncryptedFile) {
+					t.Fatalf("ExtractText = %v, want %v", err, ErrEncryptedFile)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractText: %v", err)
+			}
+			if !strings.Contains(text, "Ada Okafor") {
+				t.Errorf("text = %q, want it to contain the name", text)
+			}
+		})
+	}
+}
+
+func TestHasEncryptEntry(t *testing.T) {
+	// pdfFile lays out a body and a cross-reference section, which is
+	// where startxref points
+	pdfFile := func(body, xref string) []byte {
+		head := "%PDF-1.5\n" + body
+		return []byte(head + xref + "startxref\n" + strconv.Itoa(len(head)) + "\n%%EOF\n")
+	}
+	table := "xref\n0 1\n0000000000 65535 f \ntrailer\n<< /Size 1 /Root 1 0 R%s >>\n"
+	stream := "9 0 obj\n<< /Type /XRef /Size 10 /W [1 2 1]%s >>\nstream\n/Encrypt\nendstream\nendobj\n"
+
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"table", pdfFile("", fmt.Sprintf(table, "")), false},
+		{"table with /Encrypt", pdfFile("", fmt.Sprintf(table, " /Encrypt 5 0 R")), true},
+		{"only /EncryptMetadata", pdfFile("", fmt.Sprintf(table, " /EncryptMetadata false")), false},
+		{"/Encrypt in the body", pdfFile("1 0 obj\n(/Encrypt)\nendobj\n", fmt.Sprintf(table, "")), false},
+		{"stream", pdfFile("", fmt.Sprintf(stream, "")), false},
+		{"stream with /Encrypt", pdfFile("", fmt.Sprintf(stream, " /Encrypt 5 0 R")), true},
+		{"no startxref", []byte("%PDF-1.5\ntrailer << /Encrypt 5 0 R >>\n%%EOF\n"), false},
+		{"startxref past the end", []byte("%PDF-1.5\nstartxref\n99999\n%%EOF\n"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasEncryptEntry(tt.data); got != tt.want {
+				t.Errorf("hasEncryptEntry = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,87 +1,167 @@
 package documents
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 
 	"github.com/Emmanuella-codes/burnished-microservice/internal/ai"
+	"github.com/Emmanuella-codes/burnished-microservice/internal/cache"
 	"github.com/Emmanuella-codes/burnished-microservice/internal/config"
 	"github.com/Emmanuella-codes/burnished-microservice/internal/dtos"
 )
 
 type Processor struct {
-	config *config.Config
+	config  *config.Config
+	models  *ai.Router
+	prompts *ai.PromptStore
+	formats *FormatRegistry
+	cache   cache.Cache
 }
 
-func NewProcessor(cfg *config.Config) *Processor {
+// NewProcessor builds a Processor that reads CVs in the formats registered in
+// formats and renders prompts from prompts; results is nil when caching is off.
+func NewProcessor(cfg *config.Config, models *ai.Router, prompts *ai.PromptStore, formats *FormatRegistry, results cache.Cache) *Processor {
 	return &Processor{
-		config: cfg,
+		config:  cfg,
+		models:  models,
+		prompts: prompts,
+		formats: formats,
+		cache:   results,
 	}
 }
 
-func (p *Processor) FormatForATS(file io.Reader, fileExt, jobDesc string) (*dtos.Resume, error) {
-	var processor DocumentProcessor
-	var err error
+// FormatForATS parses and optimizes the CV, then checks the result against the
+// extracted text and applies policy to anything the model made up. The model's
+// resume is cached before that check, so any policy can reuse it; noCache
+// skips the cached copy. cached reports whether it was reused.
+func (p *Processor) FormatForATS(ctx context.Context, file io.Reader, format, jobDesc string, policy ai.FabricationPolicy, noCache bool) (resume *dtos.Resume, warnings []ai.Warning, cached bool, err error) {
+	text, err := p.extractText(ctx, file, format)
+	if err != nil {
+		return nil, nil, false, err
+	}
 
-	switch fileExt {
-	case ".pdf":
-		processor = NewPDFProcessor()
-	case ".docx":
-		processor = NewDOCXProcessor()
-	default:
-		return nil, fmt.Errorf("unsupported file format: %s", fileExt)
+	// parse + optimize CV into structured JSON
+	key := p.cacheKey(ctx, "format", text, jobDesc)
+	resume, cached, err = cache.Fetch(p.cache, key, noCache, func() (*dtos.Resume, error) {
+		return ai.ParseAndOptimizeCV(ctx, text, jobDesc, p.models.For("format"), p.prompts)
+	})
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("optimizing CV for ATS: %w", err)
+	}
+	if cached {
+		log.Printf("Reusing cached resume %s", key[:12])
 	}
 
-	// extract text from cv
-	text, err := processor.ExtractText(file)
+	// compare the optimized resume with the source text
+	warnings, err = ai.CheckGrounding(resume, text, policy)
+	if len(warnings) > 0 {
+		log.Printf("Optimized resume has %d items not found in the CV (policy %s)", len(warnings), policy)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("extracting text from %s: %w", fileExt, err)
+		return nil, nil, cached, fmt.Errorf("checking optimized CV against source: %w", err)
 	}
 
-	log.Printf("Extracted text length: %d characters", len(text))
-    if len(text) == 0 {
-        return nil, fmt.Errorf("extracted text is empty")
-    }
+	return resume, warnings, cached, nil
+}
 
-	// parse + optimize CV into structured JSON
-	resume, err := ai.ParseAndOptimizeCV(text, jobDesc, p.config.DeepSeekAPIKey)
+// RoastCV critiques the CV, streaming the critique to onDelta when it is set.
+// A cached critique is passed to onDelta in one piece.
+func (p *Processor) RoastCV(ctx context.Context, file io.Reader, format string, noCache bool, onDelta func(string)) (string, bool, error) {
+	text, err := p.extractText(ctx, file, format)
+	if err != nil {
+		return "", false, err
+	}
+
+	// use AI to critique the CV
+	feedback, cached, err := cache.Fetch(p.cache, p.cacheKey(ctx, "roast", text, ""), noCache, func() (string, error) {
+		return ai.RoastCV(ctx, text, p.models.For("roast"), p.prompts, onDelta)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("optimizing CV for ATS: %w", err)
+		return "", false, fmt.Errorf("roasting CV: %w", err)
+	}
+	if cached && onDelta != nil {
+		onDelta(feedback)
 	}
 
-	return resume, nil
+	return feedback, cached, nil
 }
 
-func (p *Processor) RoastCV(file io.Reader, fileExt string) (string, error) {
-	var processor DocumentProcessor
-	var err error
+// WriteCoverLetter writes a cover letter for the job from the CV, streaming
+// its text to onDelta when it is set. The sender block is the CV's header as
+// format mode parses it, sharing its cached resume. A cached letter is dated
+// today and its text passed to onDelta in one piece; cached reports whether
+// both the letter and the header were reused.
+func (p *Processor) WriteCoverLetter(ctx context.Context, file io.Reader, format, jobDesc string, noCache bool, onDelta func(string)) (*dtos.CoverLetter, bool, error) {
+	text, err := p.extractText(ctx, file, format)
+	if err != nil {
+		return nil, false, err
+	}
+
+	letter, cached, err := cache.Fetch(p.cache, p.cacheKey(ctx, "letter", text, jobDesc), noCache, func() (*dtos.CoverLetter, error) {
+		return ai.GenerateCoverLetter(ctx, text, jobDesc, p.models.For("letter"), p.prompts, onDelta)
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("generating cover letter: %w", err)
+	}
+
+	resume, headerCached, err := cache.Fetch(p.cache, p.cacheKey(ctx, "format", text, jobDesc), noCache, func() (*dtos.Resume, error) {
+		return ai.ParseAndOptimizeCV(ctx, text, jobDesc, p.models.For("format"), p.prompts)
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("reading CV header for cover letter: %w", err)
+	}
+	ai.SignCoverLetter(letter, resume.Header)
+
+	if cached {
+		ai.DateCoverLetter(letter)
+		if onDelta != nil {
+			onDelta(CoverLetterBody(letter))
+		}
+	}
+
+	return letter, cached && headerCached, nil
+}
 
-	switch fileExt {
-	case ".pdf":
-		processor = NewPDFProcessor()
-	case ".docx":
-		processor = NewDOCXProcessor()
-	default:
-		return "", fmt.Errorf("unsupported file format: %s", fileExt)
+// extractText reads the CV text in the named format and checks it is neither
+// empty nor over MAX_CV_TOKENS.
+func (p *Processor) extractText(ctx context.Context, file io.Reader, format string) (string, error) {
+	f, ok := p.formats.Get(format)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
 	}
 
 	// extract text from cv
-	text, err := processor.ExtractText(file)
+	text, err := f.Extractor.ExtractText(ctx, file)
 	if err != nil {
-		return "", fmt.Errorf("extracting text from %s: %w", fileExt, err)
+		// the content was sniffed as the format already, so a file that
+		// does not parse is damaged
+		if ctx.Err() == nil && !errors.Is(err, ErrEncryptedFile) && !errors.Is(err, ErrUnsupportedFormat) {
+			err = fmt.Errorf("%w: %w", ErrCorruptFile, err)
+		}
+		return "", fmt.Errorf("extracting text from %s: %w", f.Name, err)
 	}
 
-	// use AI to critique the CV
-	feedback, err := ai.RoastCV(text, p.config.DeepSeekAPIKey)
-	if err != nil {
-		return "", fmt.Errorf("roasting CV: %w", err)
+	log.Printf("Extracted text length: %d characters", len(text))
+	if len(text) == 0 {
+		return "", fmt.Errorf("extracted text is empty")
 	}
+	if err := ai.CheckInputSize(text, p.config.MaxCVTokens); err != nil {
+		return "", err
+	}
+	return text, nil
+}
 
-	return feedback, nil
+// cacheKey addresses a mode's result by everything that shapes it: the CV
+// text, the job description, the prompt versions and variants the request
+// gets and the models configured.
+func (p *Processor) cacheKey(ctx context.Context, mode, text, jobDesc string) string {
+	return cache.Key(mode, text, jobDesc, p.prompts.PromptVersion(ctx, mode), fmt.Sprint(p.config.ModelRoutes[mode]))
 }
 
 type DocumentProcessor interface {
-	ExtractText(file io.Reader) (string, error)
+	TextExtractor
 	CreateFormattedDocument(content string) ([]byte, error)
 }
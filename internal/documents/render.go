@@ -0,0 +1,102 @@
+package documents
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/dtos"
+)
+
+const (
+	OutputJSON = "json"
+	OutputPDF  = "pdf"
+	OutputDOCX = "docx"
+)
+
+const (
+	contentTypePDF  = "application/pdf"
+	contentTypeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+)
+
+// RenderedDocument is a generated file. Content is base64 encoded in JSON;
+// a reference to the file has a URL to download it from instead.
+type RenderedDocument struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Content     []byte `json:"content,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// RenderResume renders resume in the requested output format using tmpl.
+func RenderResume(resume *dtos.Resume, output string, tmpl *ResumeTemplate) (*RenderedDocument, error) {
+	switch output {
+	case OutputPDF:
+		content, err := NewResumePDFRenderer(tmpl).Render(resume)
+		if err != nil {
+			return nil, err
+		}
+		return &RenderedDocument{
+			Filename:    documentFilename(resume.Header.Fullname, "Resume", ".pdf"),
+			ContentType: contentTypePDF,
+			Content:     content,
+		}, nil
+	case OutputDOCX:
+		content, err := NewResumeDOCXRenderer(tmpl).Render(resume)
+		if err != nil {
+			return nil, err
+		}
+		return &RenderedDocument{
+			Filename:    documentFilename(resume.Header.Fullname, "Resume", ".docx"),
+			ContentType: contentTypeDOCX,
+			Content:     content,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported resume output: %s", output)
+	}
+}
+
+// RenderCoverLetter renders letter in the requested output format using tmpl.
+func RenderCoverLetter(letter *dtos.CoverLetter, output string, tmpl *ResumeTemplate) (*RenderedDocument, error) {
+	switch output {
+	case OutputPDF:
+		content, err := NewCoverLetterPDFRenderer(tmpl).Render(letter)
+		if err != nil {
+			return nil, err
+		}
+		return &RenderedDocument{
+			Filename:    documentFilename(letter.Sender.Fullname, "Cover_Letter", ".pdf"),
+			ContentType: contentTypePDF,
+			Content:     content,
+		}, nil
+	case OutputDOCX:
+		content, err := NewCoverLetterDOCXRenderer(tmpl).Render(letter)
+		if err != nil {
+			return nil, err
+		}
+		return &RenderedDocument{
+			Filename:    documentFilename(letter.Sender.Fullname, "Cover_Letter", ".docx"),
+			ContentType: contentTypeDOCX,
+			Content:     content,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported cover letter output: %s", output)
+	}
+}
+
+// documentFilename builds a download name such as "Jane_Doe_Resume.pdf".
+func documentFilename(name, kind, ext string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			b.WriteRune('_')
+		}
+	}
+	if b.Len() == 0 {
+		return kind + ext
+	}
+	return b.String() + "_" + kind + ext
+}
@@ -0,0 +1,262 @@
+package documents
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/dtos"
+	"github.com/unidoc/unioffice/color"
+	"github.com/unidoc/unioffice/document"
+	"github.com/unidoc/unioffice/measurement"
+	"github.com/unidoc/unioffice/schema/soo/wml"
+)
+
+const (
+	docxPageWidth  = 8.27 * measurement.Inch // A4, to match the PDF output
+	docxPageHeight = 11.69 * measurement.Inch
+)
+
+// ResumeDOCXRenderer lays out a structured resume as an editable Word
+// document using the built-in Title and Heading styles, so recruiters can
+// restyle it from Word's style gallery. The template is applied to those
+// styles rather than to individual runs wherever possible.
+type ResumeDOCXRenderer struct {
+	tmpl *ResumeTemplate
+}
+
+func NewResumeDOCXRenderer(tmpl *ResumeTemplate) *ResumeDOCXRenderer {
+	return &ResumeDOCXRenderer{tmpl: tmpl}
+}
+
+// resumeDOCX holds the state of one render.
+type resumeDOCX struct {
+	doc       *document.Document
+	bullets   document.NumberingDefinition
+	tmpl      *ResumeTemplate
+	bodySize  measurement.Distance
+	textWidth measurement.Distance
+	linkColor color.Color
+}
+
+func (r *ResumeDOCXRenderer) Render(resume *dtos.Resume) ([]byte, error) {
+	if resume == nil {
+		return nil, fmt.Errorf("resume cannot be nil")
+	}
+
+	tmpl := r.tmpl
+
+	doc := document.New()
+	defer doc.Close()
+
+	margin := measurement.Distance(tmpl.MarginMM) * measurement.Millimeter
+	section := doc.BodySection()
+	section.SetPageSizeAndOrientation(docxPageWidth, docxPageHeight, wml.ST_PageOrientationPortrait)
+	section.SetPageMargins(margin, margin, margin, margin, 0.4*measurement.Inch, 0.4*measurement.Inch, 0)
+
+	d := &resumeDOCX{
+		doc:       doc,
+		bullets:   bulletDefinition(doc),
+		tmpl:      tmpl,
+		bodySize:  measurement.Distance(tmpl.BodySize) * measurement.Point,
+		textWidth: docxPageWidth - 2*margin,
+		linkColor: hexColor(tmpl.LinkColor),
+	}
+	d.applyStyles()
+
+	d.header(resume.Header)
+	for _, key := range resumeSections(resume, tmpl) {
+		d.section(resume, key)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := doc.Save(buf); err != nil {
+		return nil, fmt.Errorf("saving resume DOCX: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// bulletDefinition adds a single-level bullet list with a hanging indent.
+func bulletDefinition(doc *document.Document) document.NumberingDefinition {
+	def := doc.Numbering.AddDefinition()
+	level := def.AddLevel()
+	level.SetFormat(wml.ST_NumberFormatBullet)
+	level.SetAlignment(wml.ST_JcLeft)
+	level.SetText("•")
+	level.Properties().SetLeftIndent(0.25 * measurement.Inch)
+	level.Properties().SetHangingIndent(0.18 * measurement.Inch)
+	return def
+}
+
+// applyStyles restyles Normal, Title and the headings from the template so
+// the document stays consistent when a recruiter edits it.
+func (d *resumeDOCX) applyStyles() {
+	bodyFont := docxFontFamily(d.tmpl.BodyFont)
+	headingFont := docxFontFamily(d.tmpl.HeadingFont)
+	textColor := hexColor(d.tmpl.TextColor)
+	accent := hexColor(d.tmpl.AccentColor)
+	sectionSpacing := measurement.Distance(d.tmpl.SectionSpacingMM) * measurement.Millimeter
+	entrySpacing := measurement.Distance(d.tmpl.EntrySpacingMM) * measurement.Millimeter
+
+	style := func(id, font string, size float64, c color.Color, before, after measurement.Distance) {
+		s, ok := d.doc.Styles.SearchStyleById(id)
+		if !ok {
+			return
+		}
+		s.RunProperties().SetFontFamily(font)
+		s.RunProperties().SetSize(measurement.Distance(size) * measurement.Point)
+		s.RunProperties().SetColor(c)
+		s.ParagraphProperties().SetSpacing(before, after)
+	}
+	style("Normal", bodyFont, d.tmpl.BodySize, textColor, 0, 0)
+	style("Title", headingFont, d.tmpl.NameSize, accent, 0, 2*measurement.Point)
+	style("Heading1", headingFont, d.tmpl.HeadingSize, accent, sectionSpacing, 2*measurement.Point)
+	style("Heading2", bodyFont, d.tmpl.BodySize+0.5, textColor, entrySpacing, 0)
+
+	if heading, ok := d.doc.Styles.SearchStyleById("Heading1"); ok {
+		heading.RunProperties().SetAllCaps(d.tmpl.UppercaseHeadings)
+	}
+}
+
+func (d *resumeDOCX) header(header dtos.Header) {
+	align := wml.ST_JcCenter
+	if d.tmpl.HeaderAlign == "left" {
+		align = wml.ST_JcLeft
+	}
+	if header.Fullname != "" {
+		para := d.doc.AddParagraph()
+		para.SetStyle("Title")
+		para.SetAlignment(align)
+		para.AddRun().AddText(header.Fullname)
+	}
+	if header.JobTitle != "" {
+		para := d.doc.AddParagraph()
+		para.SetAlignment(align)
+		d.text(para, header.JobTitle, measurement.Distance(d.tmpl.TitleSize)*measurement.Point)
+	}
+
+	contacts := headerContacts(header)
+	if len(contacts) == 0 {
+		return
+	}
+	para := d.doc.AddParagraph()
+	para.SetAlignment(align)
+	for i, contact := range contacts {
+		if i > 0 {
+			d.text(para, "  |  ", d.bodySize)
+		}
+		if contact.URL != "" {
+			d.link(para, contact.Text, contact.URL)
+		} else {
+			d.text(para, contact.Text, d.bodySize)
+		}
+	}
+}
+
+func (d *resumeDOCX) section(resume *dtos.Resume, key string) {
+	heading := d.doc.AddParagraph()
+	heading.SetStyle("Heading1")
+	heading.Properties().SetKeepWithNext(true)
+	if d.tmpl.HeadingRule {
+		heading.Borders().SetBottom(wml.ST_BorderSingle, hexColor(d.tmpl.AccentColor), 0.5*measurement.Point)
+	}
+	heading.AddRun().AddText(sectionTitles[key])
+
+	switch key {
+	case "profileSummary":
+		para := d.doc.AddParagraph()
+		d.text(para, strings.TrimSpace(resume.ProfileSummary), d.bodySize)
+	case "experiences":
+		for _, exp := range resume.Experiences {
+			d.entry(exp.Occupation, dateRange(exp.StartDate, exp.EndDate), joinNonEmpty(", ", exp.Company, exp.Location), "", exp.Descriptions)
+		}
+	case "education":
+		for _, edu := range resume.Education {
+			d.entry(edu.Degree, dateRange(edu.StartDate, edu.EndDate), joinNonEmpty(", ", edu.Institution, edu.Location), "", edu.Descriptions)
+		}
+	case "skills":
+		for _, skill := range resume.Skills {
+			values := joinNonEmpty(", ", skill.Values...)
+			if values == "" {
+				continue
+			}
+			para := d.doc.AddParagraph()
+			if skill.Title != "" {
+				run := para.AddRun()
+				run.Properties().SetBold(true)
+				run.Properties().SetSize(d.bodySize)
+				run.AddText(skill.Title + ": ")
+			}
+			d.text(para, values, d.bodySize)
+		}
+	case "projects":
+		for _, project := range resume.Projects {
+			d.entry(project.Title, "", project.Subtitle, absoluteURL(project.Link), project.Descriptions)
+		}
+	case "awards":
+		for _, award := range resume.Awards {
+			d.entry(award.Title, award.Date, award.Issuer, absoluteURL(award.Link), award.Descriptions)
+		}
+	}
+}
+
+// entry writes a Heading2 title with the date pushed to the right margin by
+// a right-aligned tab stop, an italic subtitle and a bulleted list.
+func (d *resumeDOCX) entry(title, date, subtitle, link string, bullets []string) {
+	para := d.doc.AddParagraph()
+	para.SetStyle("Heading2")
+	para.Properties().SetKeepWithNext(true)
+	para.Properties().AddTabStop(d.textWidth, wml.ST_TabJcRight, wml.ST_TabTlcNone)
+	if link != "" {
+		d.link(para, title, link)
+	} else {
+		para.AddRun().AddText(title)
+	}
+	if date != "" {
+		run := para.AddRun()
+		run.AddTab()
+		run.AddText(date)
+	}
+
+	if subtitle != "" {
+		sub := d.doc.AddParagraph()
+		sub.Properties().SetKeepWithNext(len(bullets) > 0)
+		run := sub.AddRun()
+		run.Properties().SetItalic(true)
+		run.Properties().SetSize(d.bodySize)
+		run.AddText(subtitle)
+	}
+
+	for _, bullet := range bullets {
+		bullet = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(bullet), "-•*"))
+		if bullet == "" {
+			continue
+		}
+		item := d.doc.AddParagraph()
+		item.SetNumberingDefinition(d.bullets)
+		item.SetNumberingLevel(0)
+		d.text(item, bullet, d.bodySize)
+	}
+}
+
+func (d *resumeDOCX) text(para document.Paragraph, text string, size measurement.Distance) {
+	run := para.AddRun()
+	run.Properties().SetSize(size)
+	run.AddText(text)
+}
+
+func hexColor(hex string) color.Color {
+	r, g, b := rgb(hex)
+	return color.RGB(uint8(r), uint8(g), uint8(b))
+}
+
+func (d *resumeDOCX) link(para document.Paragraph, text, url string) {
+	link := para.AddHyperLink()
+	link.SetTarget(url)
+	link.SetToolTip(url)
+	run := link.AddRun()
+	run.Properties().SetColor(d.linkColor)
+	run.Properties().SetUnderline(wml.ST_UnderlineSingle, d.linkColor)
+	run.Properties().SetSize(d.bodySize)
+	run.AddText(text)
+}
@@ -0,0 +1,264 @@
+package documents
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/dtos"
+	"github.com/jung-kurt/gofpdf"
+)
+
+const bulletIndent = 5.0
+
+// ResumePDFRenderer lays out a structured resume as a single-column PDF with
+// real, selectable text so applicant tracking systems can parse it.
+type ResumePDFRenderer struct {
+	tmpl *ResumeTemplate
+}
+
+func NewResumePDFRenderer(tmpl *ResumeTemplate) *ResumePDFRenderer {
+	return &ResumePDFRenderer{tmpl: tmpl}
+}
+
+// resumePDF holds the state of one render.
+type resumePDF struct {
+	pdf  *gofpdf.Fpdf
+	w    *pdfWriter
+	tmpl *ResumeTemplate
+
+	headingFont string
+	bodyFont    string
+	lineHeight  float64
+}
+
+func (r *ResumePDFRenderer) Render(resume *dtos.Resume) ([]byte, error) {
+	if resume == nil {
+		return nil, fmt.Errorf("resume cannot be nil")
+	}
+	tmpl := r.tmpl
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(tmpl.MarginMM, tmpl.MarginMM, tmpl.MarginMM)
+	pdf.SetAutoPageBreak(true, tmpl.MarginMM)
+	pdf.SetTitle(resume.Header.Fullname, true)
+	pdf.SetAuthor(resume.Header.Fullname, true)
+	pdf.AddPage()
+	pdf.SetTextColor(rgb(tmpl.TextColor))
+
+	doc := &resumePDF{
+		pdf:         pdf,
+		w:           newPDFWriter(pdf),
+		tmpl:        tmpl,
+		headingFont: pdfFontFamily(tmpl.HeadingFont),
+		bodyFont:    pdfFontFamily(tmpl.BodyFont),
+		lineHeight:  tmpl.BodySize * ptToMM * tmpl.LineSpacing,
+	}
+
+	doc.header(resume.Header)
+	for _, section := range resumeSections(resume, tmpl) {
+		doc.section(resume, section)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("generating resume PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *resumePDF) header(header dtos.Header) {
+	align := "C"
+	if d.tmpl.HeaderAlign == "left" {
+		align = "L"
+	}
+	if header.Fullname != "" {
+		d.w.SetFont(d.headingFont, "B", d.tmpl.NameSize)
+		d.pdf.SetTextColor(rgb(d.tmpl.AccentColor))
+		d.w.Cell(0, d.tmpl.NameSize*ptToMM*1.3, header.Fullname, 1, align, "")
+		d.pdf.SetTextColor(rgb(d.tmpl.TextColor))
+	}
+	if header.JobTitle != "" {
+		d.w.SetFont(d.bodyFont, "", d.tmpl.TitleSize)
+		d.w.Cell(0, d.tmpl.TitleSize*ptToMM*1.4, header.JobTitle, 1, align, "")
+	}
+
+	contactSize := d.tmpl.BodySize - 1
+	d.w.SetFont(d.bodyFont, "", contactSize)
+	d.contactLines(headerContacts(header), contactSize*ptToMM*1.4)
+	d.pdf.Ln(d.tmpl.SectionSpacingMM / 2)
+}
+
+// contactLines lays out the contact entries, wrapping onto further lines
+// when they do not fit, and makes entries with a URL clickable.
+func (d *resumePDF) contactLines(contacts []contactLink, h float64) {
+	const sep = "  |  "
+	pageW, _ := d.pdf.GetPageSize()
+	left, _, right, _ := d.pdf.GetMargins()
+	maxW := pageW - left - right
+	sepW := d.w.Width(sep)
+
+	for len(contacts) > 0 {
+		lineW := 0.0
+		n := 0
+		for n < len(contacts) {
+			w := d.w.Width(contacts[n].Text)
+			if n > 0 {
+				w += sepW
+			}
+			if n > 0 && lineW+w > maxW {
+				break
+			}
+			lineW += w
+			n++
+		}
+
+		if d.tmpl.HeaderAlign == "left" {
+			d.pdf.SetX(left)
+		} else {
+			d.pdf.SetX(left + (maxW-lineW)/2)
+		}
+		for i, contact := range contacts[:n] {
+			if i > 0 {
+				d.w.Cell(sepW, h, sep, 0, "L", "")
+			}
+			if contact.URL != "" {
+				d.pdf.SetTextColor(rgb(d.tmpl.LinkColor))
+			}
+			d.w.Cell(d.w.Width(contact.Text), h, contact.Text, 0, "L", contact.URL)
+			d.pdf.SetTextColor(rgb(d.tmpl.TextColor))
+		}
+		d.pdf.Ln(h)
+		contacts = contacts[n:]
+	}
+}
+
+func (d *resumePDF) section(resume *dtos.Resume, key string) {
+	d.heading(sectionTitles[key])
+
+	switch key {
+	case "profileSummary":
+		d.w.SetFont(d.bodyFont, "", d.tmpl.BodySize)
+		d.w.MultiCell(0, d.lineHeight, strings.TrimSpace(resume.ProfileSummary), "L")
+	case "experiences":
+		for _, exp := range resume.Experiences {
+			d.entry(exp.Occupation, dateRange(exp.StartDate, exp.EndDate), joinNonEmpty(", ", exp.Company, exp.Location), "", exp.Descriptions)
+		}
+	case "education":
+		for _, edu := range resume.Education {
+			d.entry(edu.Degree, dateRange(edu.StartDate, edu.EndDate), joinNonEmpty(", ", edu.Institution, edu.Location), "", edu.Descriptions)
+		}
+	case "skills":
+		for _, skill := range resume.Skills {
+			d.skillLine(skill)
+		}
+	case "projects":
+		for _, project := range resume.Projects {
+			d.entry(project.Title, "", project.Subtitle, absoluteURL(project.Link), project.Descriptions)
+		}
+	case "awards":
+		for _, award := range resume.Awards {
+			d.entry(award.Title, award.Date, award.Issuer, absoluteURL(award.Link), award.Descriptions)
+		}
+	}
+	d.pdf.Ln(d.tmpl.SectionSpacingMM / 2)
+}
+
+// heading starts a section, moving to a new page first if the heading and
+// the first lines under it would not fit.
+func (d *resumePDF) heading(title string) {
+	headingH := d.tmpl.HeadingSize * ptToMM * 1.5
+	d.keepTogether(d.tmpl.SectionSpacingMM + headingH + 3*d.lineHeight)
+
+	if d.tmpl.UppercaseHeadings {
+		title = strings.ToUpper(title)
+	}
+	d.pdf.Ln(d.tmpl.SectionSpacingMM / 2)
+	d.w.SetFont(d.headingFont, "B", d.tmpl.HeadingSize)
+	d.pdf.SetTextColor(rgb(d.tmpl.AccentColor))
+	d.w.Cell(0, headingH, title, 1, "L", "")
+	d.pdf.SetTextColor(rgb(d.tmpl.TextColor))
+
+	if d.tmpl.HeadingRule {
+		left, _, right, _ := d.pdf.GetMargins()
+		pageW, _ := d.pdf.GetPageSize()
+		y := d.pdf.GetY()
+		d.pdf.SetDrawColor(rgb(d.tmpl.AccentColor))
+		d.pdf.SetLineWidth(0.3)
+		d.pdf.Line(left, y, pageW-right, y)
+	}
+	d.pdf.Ln(1.5)
+}
+
+// entry renders a title with a right-aligned date, a subtitle line and
+// bullet points, as used for jobs, degrees, projects and awards.
+func (d *resumePDF) entry(title, date, subtitle, link string, bullets []string) {
+	d.keepTogether(3 * d.lineHeight)
+
+	left, _, right, _ := d.pdf.GetMargins()
+	pageW, _ := d.pdf.GetPageSize()
+	width := pageW - left - right
+
+	d.w.SetFont(d.bodyFont, "", d.tmpl.BodySize)
+	dateW := 0.0
+	if date != "" {
+		dateW = d.w.Width(date) + 2
+	}
+
+	d.w.SetFont(d.bodyFont, "B", d.tmpl.BodySize+0.5)
+	if link != "" {
+		d.pdf.SetTextColor(rgb(d.tmpl.LinkColor))
+	}
+	d.w.Cell(width-dateW, d.lineHeight, title, 0, "L", link)
+	d.pdf.SetTextColor(rgb(d.tmpl.TextColor))
+	d.w.SetFont(d.bodyFont, "", d.tmpl.BodySize)
+	d.w.Cell(dateW, d.lineHeight, date, 1, "R", "")
+
+	if subtitle != "" {
+		d.w.SetFont(d.bodyFont, "I", d.tmpl.BodySize)
+		d.w.MultiCell(0, d.lineHeight, subtitle, "L")
+	}
+
+	d.w.SetFont(d.bodyFont, "", d.tmpl.BodySize)
+	for _, bullet := range bullets {
+		d.bullet(bullet)
+	}
+	d.pdf.Ln(d.tmpl.EntrySpacingMM)
+}
+
+// bullet writes a hanging-indent bullet point: wrapped lines align with the
+// text, not the bullet.
+func (d *resumePDF) bullet(text string) {
+	text = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(text), "-•*"))
+	if text == "" {
+		return
+	}
+	left, _, _, _ := d.pdf.GetMargins()
+	d.pdf.SetX(left + 1)
+	d.w.Cell(bulletIndent-1, d.lineHeight, "•", 0, "L", "")
+	d.w.MultiCell(0, d.lineHeight, text, "L")
+}
+
+func (d *resumePDF) skillLine(skill dtos.Skills) {
+	values := joinNonEmpty(", ", skill.Values...)
+	if values == "" {
+		return
+	}
+	if skill.Title != "" {
+		d.w.SetFont(d.bodyFont, "B", d.tmpl.BodySize)
+		d.w.Write(d.lineHeight, skill.Title+": ")
+	}
+	d.w.SetFont(d.bodyFont, "", d.tmpl.BodySize)
+	d.w.Write(d.lineHeight, values)
+	d.pdf.Ln(d.lineHeight + d.tmpl.EntrySpacingMM/2)
+}
+
+// keepTogether starts a new page when less than h remains, so headings and
+// entry titles are never stranded at the bottom of a page.
+func (d *resumePDF) keepTogether(h float64) {
+	_, pageH := d.pdf.GetPageSize()
+	_, _, _, bottom := d.pdf.GetMargins()
+	if d.pdf.GetY()+h > pageH-bottom {
+		d.pdf.AddPage()
+	}
+}
@@ -0,0 +1,136 @@
+package documents
+
+import (
+	"strings"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/dtos"
+)
+
+var defaultSectionOrder = []string{
+	"header", "profileSummary", "experiences",
+	"education", "skills", "projects", "awards",
+}
+
+var sectionTitles = map[string]string{
+	"profileSummary": "Profile Summary",
+	"experiences":    "Experience",
+	"education":      "Education",
+	"skills":         "Skills",
+	"projects":       "Projects",
+	"awards":         "Awards",
+}
+
+// resumeSections returns the non-empty body sections of resume in the order
+// the model chose, unless the template fixes its own order. Sections left out
+// of the order are appended in the default order so nothing is dropped; the
+// header is always rendered first and is not included.
+func resumeSections(resume *dtos.Resume, tmpl *ResumeTemplate) []string {
+	seen := make(map[string]bool)
+	var sections []string
+	add := func(key string) {
+		if key == "header" || seen[key] || !hasSection(resume, key) {
+			return
+		}
+		seen[key] = true
+		sections = append(sections, key)
+	}
+	order := resume.SectionOrder
+	if len(tmpl.SectionOrder) > 0 {
+		order = tmpl.SectionOrder
+	}
+	for _, key := range order {
+		add(key)
+	}
+	for _, key := range defaultSectionOrder {
+		add(key)
+	}
+	return sections
+}
+
+func hasSection(resume *dtos.Resume, key string) bool {
+	switch key {
+	case "profileSummary":
+		return strings.TrimSpace(resume.ProfileSummary) != ""
+	case "experiences":
+		return len(resume.Experiences) > 0
+	case "education":
+		return len(resume.Education) > 0
+	case "skills":
+		return len(resume.Skills) > 0
+	case "projects":
+		return len(resume.Projects) > 0
+	case "awards":
+		return len(resume.Awards) > 0
+	}
+	return false
+}
+
+// dateRange joins start and end dates as "Jan 2020 – Present".
+func dateRange(start, end string) string {
+	start, end = strings.TrimSpace(start), strings.TrimSpace(end)
+	switch {
+	case start != "" && end != "":
+		return start + " – " + end
+	case start != "":
+		return start
+	default:
+		return end
+	}
+}
+
+// joinNonEmpty joins the non-blank parts with sep.
+func joinNonEmpty(sep string, parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+// contactLink is one entry on the header contact line.
+type contactLink struct {
+	Text string
+	URL  string
+}
+
+// headerContacts lists the contact details shown under the name, with link
+// targets for email and profile URLs.
+func headerContacts(header dtos.Header) []contactLink {
+	var contacts []contactLink
+	add := func(text, url string) {
+		text, url = strings.TrimSpace(text), strings.TrimSpace(url)
+		if text == "" {
+			text = displayURL(url)
+		}
+		if text == "" {
+			return
+		}
+		contacts = append(contacts, contactLink{Text: text, URL: url})
+	}
+	add(header.Location, "")
+	if header.Email != "" {
+		add(header.Email, "mailto:"+strings.TrimSpace(header.Email))
+	}
+	add(header.Phone, "")
+	add(header.LinkedIn, absoluteURL(header.LinkedInURL))
+	add(header.Github, absoluteURL(header.GithubURL))
+	add(header.Website, absoluteURL(header.WebsiteURL))
+	return contacts
+}
+
+// absoluteURL adds a scheme to bare URLs such as "github.com/jane" so PDF and
+// Word viewers treat them as web links.
+func absoluteURL(url string) string {
+	url = strings.TrimSpace(url)
+	if url == "" || strings.Contains(url, "://") || strings.HasPrefix(url, "mailto:") {
+		return url
+	}
+	return "https://" + url
+}
+
+func displayURL(url string) string {
+	url = strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://")
+	return strings.TrimSuffix(url, "/")
+}
@@ -0,0 +1,376 @@
+package documents
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// rtfSkipped are destinations whose content is not text of the document.
+var rtfSkipped = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true,
+	"pict": true, "object": true, "listtable": true, "listoverridetable": true,
+	"rsidtbl": true, "revtbl": true, "filetbl": true, "generator": true,
+	"themedata": true, "colorschememapping": true, "latentstyles": true,
+	"datastore": true, "xmlnstbl": true, "sp": true, "pgdsctbl": true,
+	"mmathPr": true, "bkmkstart": true, "bkmkend": true, "nonshppict": true,
+}
+
+// rtfCodePages are the ANSI code pages \'hh escapes can be in. Others are
+// read as Windows-1252.
+var rtfCodePages = map[int]*charmap.Charmap{
+	437: charmap.CodePage437, 850: charmap.CodePage850, 852: charmap.CodePage852,
+	866: charmap.CodePage866, 874: charmap.Windows874, 1250: charmap.Windows1250,
+	1251: charmap.Windows1251, 1252: charmap.Windows1252, 1253: charmap.Windows1253,
+	1254: charmap.Windows1254, 1255: charmap.Windows1255, 1256: charmap.Windows1256,
+	1257: charmap.Windows1257, 1258: charmap.Windows1258, 10000: charmap.Macintosh,
+}
+
+// rtfSymbols are control words that stand for a character.
+var rtfSymbols = map[string]string{
+	"tab": " ", "bullet": "•", "emdash": "—", "endash": "–", "emspace": " ",
+	"enspace": " ", "qmspace": " ", "lquote": "‘", "rquote": "’",
+	"ldblquote": "“", "rdblquote": "”", "zwj": "", "zwnj": "",
+}
+
+type RTFExtractor struct{}
+
+func NewRTFExtractor() *RTFExtractor {
+	return &RTFExtractor{}
+}
+
+// ExtractText reads an RTF CV: paragraphs and list items on lines of their
+// own and table rows as cells joined by " | ", followed by the targets of
+// its HYPERLINK fields.
+func (e *RTFExtractor) ExtractText(ctx context.Context, file io.Reader) (string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("reading RTF file: %w", err)
+	}
+	if !strings.HasPrefix(string(data), "{\\rtf") {
+		return "", fmt.Errorf("parsing RTF: missing {\\rtf header")
+	}
+
+	r := &rtfReader{data: data, codePage: charmap.Windows1252}
+	r.group.unicodeSkip = 1
+	if err := r.read(); err != nil {
+		return "", fmt.Errorf("parsing RTF: %w", err)
+	}
+	r.breakLine()
+	return appendLinks(joinLines(r.lines), r.links), nil
+}
+
+// rtfGroup is the state a group starts with a copy of and restores on close.
+type rtfGroup struct {
+	skip        bool
+	hidden      bool
+	inTable     bool
+	unicodeSkip int
+	// field is the field this group is part of, if any
+	field *rtfField
+	// dest is the destination the group is in, e.g. "fldinst"
+	dest string
+	// started is set once the group's first control word is read; ignorable
+	// is set by a \* before it
+	started, ignorable bool
+	// listText buffers the number or bullet of a list item
+	listText *strings.Builder
+}
+
+// rtfField is a {\field} group: its instruction and where its result starts.
+type rtfField struct {
+	instruction strings.Builder
+	startLine   int
+	startText   string
+}
+
+type rtfReader struct {
+	data []byte
+	pos  int
+
+	group    rtfGroup
+	stack    []rtfGroup
+	codePage *charmap.Charmap
+	// pendingSkip counts the fallback characters still to drop after \u
+	pendingSkip int
+	// highSurrogate is the first half of a \u surrogate pair
+	highSurrogate rune
+
+	lines []string
+	line  strings.Builder
+	cells []string
+	links []link
+}
+
+func (r *rtfReader) read() error {
+	for r.pos < len(r.data) {
+		c := r.data[r.pos]
+		switch c {
+		case '{':
+			r.pos++
+			r.stack = append(r.stack, r.group)
+			r.group.started, r.group.ignorable = false, false
+		case '}':
+			r.pos++
+			if len(r.stack) == 0 {
+				return nil // text after the document's group is ignored
+			}
+			r.endGroup()
+		case '\\':
+			if err := r.control(); err != nil {
+				return err
+			}
+		case '\r', '\n':
+			r.pos++
+		default:
+			r.pos++
+			r.char(string(rune(c)))
+		}
+	}
+	return nil
+}
+
+// endGroup closes a group, finishing the field or list number it ends.
+func (r *rtfReader) endGroup() {
+	closed := r.group
+	r.group = r.stack[len(r.stack)-1]
+	r.stack = r.stack[:len(r.stack)-1]
+
+	switch {
+	case closed.field != nil && closed.field != r.group.field:
+		if m := hyperlinkField.FindStringSubmatch(closed.field.instruction.String()); m != nil {
+			r.links = append(r.links, link{text: textSince(r.lines, r.line.String(), closed.field.startLine, closed.field.startText), url: m[1]})
+		}
+	case closed.listText != nil && r.group.listText == nil:
+		label := strings.TrimSpace(closed.listText.String())
+		// a bullet is a Symbol font character, which reads as "·" or less
+		if len([]rune(label)) <= 1 && !strings.ContainsAny(label, "0123456789") {
+			label = "•"
+		}
+		r.write(label + " ")
+	}
+}
+
+// control reads a control word or symbol at r.pos.
+func (r *rtfReader) control() error {
+	r.pos++
+	if r.pos >= len(r.data) {
+		return nil
+	}
+	c := r.data[r.pos]
+	if !isASCIILetter(c) {
+		r.pos++
+		r.symbol(c)
+		return nil
+	}
+
+	start := r.pos
+	for r.pos < len(r.data) && isASCIILetter(r.data[r.pos]) {
+		r.pos++
+	}
+	word := string(r.data[start:r.pos])
+	param, hasParam := 0, false
+	numStart := r.pos
+	if r.pos < len(r.data) && r.data[r.pos] == '-' {
+		r.pos++
+	}
+	for r.pos < len(r.data) && r.data[r.pos] >= '0' && r.data[r.pos] <= '9' {
+		r.pos++
+	}
+	if r.pos > numStart && string(r.data[numStart:r.pos]) != "-" {
+		param, _ = strconv.Atoi(string(r.data[numStart:r.pos]))
+		hasParam = true
+	} else {
+		r.pos = numStart
+	}
+	// a space ends a control word and is part of it
+	if r.pos < len(r.data) && r.data[r.pos] == ' ' {
+		r.pos++
+	}
+
+	if word == "bin" {
+		r.pos = min(r.pos+max(param, 0), len(r.data))
+		return nil
+	}
+	r.word(word, param, hasParam)
+	return nil
+}
+
+// symbol handles a control symbol such as \' or \~.
+func (r *rtfReader) symbol(c byte) {
+	switch c {
+	case '\'':
+		if r.pos+2 > len(r.data) {
+			return
+		}
+		b, err := strconv.ParseUint(string(r.data[r.pos:r.pos+2]), 16, 8)
+		r.pos += 2
+		if err != nil {
+			return
+		}
+		r.char(string(r.codePage.DecodeByte(byte(b))))
+	case '*':
+		// an unknown destination marked ignorable; known ones are read
+		if !r.group.started {
+			r.group.ignorable = true
+		}
+	case '~':
+		r.char(" ")
+	case '_':
+		r.char("-")
+	case '\\', '{', '}':
+		r.char(string(c))
+	case '\r', '\n':
+		r.paragraph()
+	case '-':
+		// optional hyphen
+	}
+}
+
+func (r *rtfReader) word(word string, param int, hasParam bool) {
+	// the first control word of a group names its destination
+	starting := !r.group.started
+	r.group.started = true
+
+	switch {
+	case rtfSkipped[word]:
+		r.group.skip = true
+		return
+	case word == "fldinst":
+		if r.group.field != nil {
+			r.group.dest = "fldinst"
+		} else {
+			r.group.skip = true
+		}
+		return
+	case word == "field":
+		r.group.field = &rtfField{}
+		return
+	case word == "fldrslt":
+		if f := r.group.field; f != nil {
+			f.startLine, f.startText = len(r.lines), r.line.String()
+		}
+		r.group.dest = "fldrslt"
+		return
+	case word == "listtext" || word == "pntext":
+		r.group.listText = &strings.Builder{}
+		return
+	case starting && r.group.ignorable && word != "shptxt" && word != "shpinst" && word != "shp":
+		r.group.skip = true
+		return
+	}
+	if r.group.skip {
+		return
+	}
+
+	switch word {
+	case "ansicpg":
+		if cp, ok := rtfCodePages[param]; ok {
+			r.codePage = cp
+		}
+	case "uc":
+		r.group.unicodeSkip = param
+	case "u":
+		if param < 0 {
+			param += 0x10000
+		}
+		r.unicode(rune(param))
+		r.pendingSkip = r.group.unicodeSkip
+	case "v":
+		r.group.hidden = !hasParam || param != 0
+	case "pard":
+		r.group.inTable = false
+	case "intbl":
+		r.group.inTable = true
+	case "par", "sect", "page":
+		r.paragraph()
+	case "line":
+		r.breakLine()
+	case "cell", "nestcell":
+		r.cells = append(r.cells, strings.TrimSpace(r.line.String()))
+		r.line.Reset()
+	case "row", "nestrow":
+		r.row()
+	default:
+		if s, ok := rtfSymbols[word]; ok {
+			r.char(s)
+		}
+	}
+}
+
+// unicode writes a \u character, joining surrogate pairs.
+func (r *rtfReader) unicode(c rune) {
+	switch {
+	case utf16.IsSurrogate(c) && c < 0xDC00:
+		r.highSurrogate = c
+	case utf16.IsSurrogate(c):
+		r.write(string(utf16.DecodeRune(r.highSurrogate, c)))
+		r.highSurrogate = 0
+	default:
+		r.write(string(c))
+	}
+}
+
+// char writes document text, dropping the fallback of a \u character.
+func (r *rtfReader) char(s string) {
+	if r.pendingSkip > 0 {
+		r.pendingSkip--
+		return
+	}
+	r.write(s)
+}
+
+func (r *rtfReader) write(s string) {
+	switch {
+	case r.group.skip || r.group.hidden:
+	case r.group.dest == "fldinst":
+		r.group.field.instruction.WriteString(s)
+	case r.group.listText != nil:
+		r.group.listText.WriteString(s)
+	default:
+		r.line.WriteString(s)
+	}
+}
+
+// paragraph ends a paragraph, which inside a table cell only adds a space.
+func (r *rtfReader) paragraph() {
+	if r.group.skip || r.group.hidden {
+		return
+	}
+	if r.group.inTable {
+		r.line.WriteString(" ")
+		return
+	}
+	r.breakLine()
+}
+
+func (r *rtfReader) row() {
+	var cells []string
+	for _, cell := range append(r.cells, strings.TrimSpace(r.line.String())) {
+		if cell = strings.Join(strings.Fields(cell), " "); cell != "" {
+			cells = append(cells, cell)
+		}
+	}
+	r.cells = nil
+	r.line.Reset()
+	if len(cells) > 0 {
+		r.lines = append(r.lines, strings.Join(cells, " | "))
+	}
+}
+
+func (r *rtfReader) breakLine() {
+	if r.group.skip || r.group.hidden {
+		return
+	}
+	r.lines = append(r.lines, strings.Join(strings.Fields(r.line.String()), " "))
+	r.line.Reset()
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
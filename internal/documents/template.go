@@ -0,0 +1,253 @@
+package documents
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const DefaultTemplate = "classic"
+
+// ResumeTemplate controls the look of rendered resumes. Sizes are in points
+// and distances in millimetres; both renderers convert as needed.
+type ResumeTemplate struct {
+	Name string `json:"name"`
+	// Base names the template a file-based template starts from; any field
+	// the file leaves out keeps the base value.
+	Base string `json:"base,omitempty"`
+
+	HeadingFont string `json:"headingFont"`
+	BodyFont    string `json:"bodyFont"`
+
+	NameSize    float64 `json:"nameSize"`
+	TitleSize   float64 `json:"titleSize"`
+	HeadingSize float64 `json:"headingSize"`
+	BodySize    float64 `json:"bodySize"`
+	LineSpacing float64 `json:"lineSpacing"` // multiple of the body size
+
+	TextColor   string `json:"textColor"` // hex, e.g. "#222222"
+	AccentColor string `json:"accentColor"`
+	LinkColor   string `json:"linkColor"`
+
+	MarginMM         float64 `json:"marginMM"`
+	SectionSpacingMM float64 `json:"sectionSpacingMM"`
+	EntrySpacingMM   float64 `json:"entrySpacingMM"`
+
+	HeaderAlign       string `json:"headerAlign"` // "center" or "left"
+	UppercaseHeadings bool   `json:"uppercaseHeadings"`
+	HeadingRule       bool   `json:"headingRule"`
+
+	// SectionOrder, when set, overrides the order chosen by the model.
+	SectionOrder []string `json:"sectionOrder,omitempty"`
+}
+
+var builtinTemplates = []ResumeTemplate{
+	{
+		Name:        "classic",
+		HeadingFont: "Helvetica", BodyFont: "Helvetica",
+		NameSize: 20, TitleSize: 12, HeadingSize: 11, BodySize: 10, LineSpacing: 1.4,
+		TextColor: "#000000", AccentColor: "#000000", LinkColor: "#0000A0",
+		MarginMM: 18, SectionSpacingMM: 3, EntrySpacingMM: 1.5,
+		HeaderAlign: "center", UppercaseHeadings: true, HeadingRule: true,
+	},
+	{
+		Name:        "modern",
+		HeadingFont: "Helvetica", BodyFont: "Helvetica",
+		NameSize: 24, TitleSize: 13, HeadingSize: 12, BodySize: 10, LineSpacing: 1.45,
+		TextColor: "#222222", AccentColor: "#1F6F8B", LinkColor: "#1F6F8B",
+		MarginMM: 16, SectionSpacingMM: 4, EntrySpacingMM: 2,
+		HeaderAlign: "left", UppercaseHeadings: false, HeadingRule: true,
+	},
+	{
+		Name:        "compact",
+		HeadingFont: "Helvetica", BodyFont: "Helvetica",
+		NameSize: 16, TitleSize: 10, HeadingSize: 10, BodySize: 9, LineSpacing: 1.3,
+		TextColor: "#000000", AccentColor: "#333333", LinkColor: "#0000A0",
+		MarginMM: 12, SectionSpacingMM: 1.5, EntrySpacingMM: 0.8,
+		HeaderAlign: "center", UppercaseHeadings: true, HeadingRule: false,
+	},
+	{
+		Name:        "academic",
+		HeadingFont: "Times", BodyFont: "Times",
+		NameSize: 18, TitleSize: 12, HeadingSize: 12, BodySize: 11, LineSpacing: 1.4,
+		TextColor: "#000000", AccentColor: "#000000", LinkColor: "#000000",
+		MarginMM: 25, SectionSpacingMM: 4, EntrySpacingMM: 2,
+		HeaderAlign: "center", UppercaseHeadings: false, HeadingRule: true,
+		SectionOrder: []string{"profileSummary", "education", "experiences", "projects", "awards", "skills"},
+	},
+}
+
+// TemplateRegistry holds the templates a request can select by name.
+type TemplateRegistry struct {
+	mu        sync.RWMutex
+	templates map[string]*ResumeTemplate
+}
+
+// NewTemplateRegistry returns a registry holding the built-in templates.
+func NewTemplateRegistry() *TemplateRegistry {
+	r := &TemplateRegistry{templates: make(map[string]*ResumeTemplate)}
+	for i := range builtinTemplates {
+		t := builtinTemplates[i]
+		t.SectionOrder = append([]string(nil), t.SectionOrder...)
+		if err := r.Register(&t); err != nil {
+			panic(err) // built-ins are static, so this is a programming error
+		}
+	}
+	return r
+}
+
+// Register adds or replaces a template.
+func (r *TemplateRegistry) Register(t *ResumeTemplate) error {
+	if err := t.validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.templates[strings.ToLower(t.Name)] = t
+	return nil
+}
+
+// Get looks a template up by name; an empty name selects DefaultTemplate.
+func (r *TemplateRegistry) Get(name string) (*ResumeTemplate, bool) {
+	if name == "" {
+		name = DefaultTemplate
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.templates[strings.ToLower(name)]
+	return t, ok
+}
+
+// Names lists the registered template names in alphabetical order.
+func (r *TemplateRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadDir registers every *.json template in dir. A file may set "base" to
+// start from an already registered template and override only some fields.
+func (r *TemplateRegistry) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("listing templates: %w", err)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading template %s: %w", path, err)
+		}
+
+		var probe struct {
+			Base string `json:"base"`
+		}
+		if err := json.Unmarshal(data, &probe); err != nil {
+			return fmt.Errorf("parsing template %s: %w", path, err)
+		}
+
+		var t ResumeTemplate
+		if probe.Base != "" {
+			base, ok := r.Get(probe.Base)
+			if !ok {
+				return fmt.Errorf("template %s: unknown base %q", path, probe.Base)
+			}
+			t = *base
+			t.SectionOrder = nil
+		}
+		if err := json.Unmarshal(data, &t); err != nil {
+			return fmt.Errorf("parsing template %s: %w", path, err)
+		}
+		if t.Name == "" {
+			t.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+		}
+		if err := r.Register(&t); err != nil {
+			return fmt.Errorf("template %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func (t *ResumeTemplate) validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("template name is required")
+	}
+	if t.BodyFont == "" || t.HeadingFont == "" {
+		return fmt.Errorf("template %s: fonts are required", t.Name)
+	}
+	if t.NameSize <= 0 || t.TitleSize <= 0 || t.HeadingSize <= 0 || t.BodySize <= 0 {
+		return fmt.Errorf("template %s: font sizes must be positive", t.Name)
+	}
+	if t.LineSpacing < 1 {
+		return fmt.Errorf("template %s: lineSpacing must be at least 1", t.Name)
+	}
+	if t.MarginMM <= 0 {
+		return fmt.Errorf("template %s: marginMM must be positive", t.Name)
+	}
+	for _, c := range []string{t.TextColor, t.AccentColor, t.LinkColor} {
+		if _, _, _, err := parseHexColor(c); err != nil {
+			return fmt.Errorf("template %s: %w", t.Name, err)
+		}
+	}
+	if t.HeaderAlign != "center" && t.HeaderAlign != "left" {
+		return fmt.Errorf("template %s: headerAlign must be center or left", t.Name)
+	}
+	return nil
+}
+
+// parseHexColor parses "#RRGGBB".
+func parseHexColor(hex string) (r, g, b uint8, err error) {
+	s := strings.TrimPrefix(hex, "#")
+	if len(s) != 6 {
+		return 0, 0, 0, fmt.Errorf("invalid colour %q", hex)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid colour %q", hex)
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), nil
+}
+
+// rgb returns the colour's components; templates are validated on
+// registration so the parse cannot fail here.
+func rgb(hex string) (int, int, int) {
+	r, g, b, _ := parseHexColor(hex)
+	return int(r), int(g), int(b)
+}
+
+// pdfFontFamily maps a template font name to the closest gofpdf core font.
+func pdfFontFamily(name string) string {
+	switch strings.ToLower(name) {
+	case "times", "times new roman", "georgia", "garamond", "cambria", "serif":
+		return "Times"
+	case "courier", "courier new", "monospace":
+		return "Courier"
+	default:
+		return "Helvetica"
+	}
+}
+
+// docxFontFamily maps a template font name to a font Word installs by default.
+func docxFontFamily(name string) string {
+	switch strings.ToLower(name) {
+	case "helvetica", "sans-serif":
+		return "Arial"
+	case "times", "serif":
+		return "Times New Roman"
+	case "courier", "monospace":
+		return "Courier New"
+	default:
+		return name
+	}
+}
+
+const ptToMM = 25.4 / 72
@@ -0,0 +1,59 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+type PlainTextExtractor struct{}
+
+func NewPlainTextExtractor() *PlainTextExtractor {
+	return &PlainTextExtractor{}
+}
+
+// ExtractText reads a plain text CV in UTF-8, in UTF-16 with a byte order
+// mark, or else in Windows-1252, which is what older editors save.
+func (e *PlainTextExtractor) ExtractText(ctx context.Context, file io.Reader) (string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("reading text file: %w", err)
+	}
+	text, err := decodeText(data)
+	if err != nil {
+		return "", fmt.Errorf("decoding text file: %w", err)
+	}
+	return strings.TrimSpace(text) + "\n", nil
+}
+
+// decodeText decodes text by its byte order mark, as UTF-8 when it is valid,
+// or else as Windows-1252, and gives it Unix line endings.
+func decodeText(data []byte) (string, error) {
+	var decoder *encoding.Decoder
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		data = data[3:]
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}), bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		decoder = unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder()
+	case !utf8.Valid(data):
+		decoder = charmap.Windows1252.NewDecoder()
+	}
+	if decoder != nil {
+		decoded, err := decoder.Bytes(data)
+		if err != nil {
+			return "", err
+		}
+		data = decoded
+	}
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	return strings.ReplaceAll(text, "\x00", ""), nil
+}
@@ -0,0 +1,18 @@
+package dtos
+
+type CoverLetter struct {
+	Sender     Header    `json:"sender"`
+	Date       string    `json:"date"`
+	Recipient  Recipient `json:"recipient"`
+	Salutation string    `json:"salutation"`
+	Paragraphs []string  `json:"paragraphs"`
+	Closing    string    `json:"closing"`
+	Signature  string    `json:"signature"`
+}
+
+type Recipient struct {
+	Name    string `json:"name,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Company string `json:"company,omitempty"`
+	Address string `json:"address,omitempty"`
+}
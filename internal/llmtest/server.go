@@ -0,0 +1,174 @@
+// Package llmtest fakes an OpenAI-compatible chat completions API, the one
+// DeepSeek serves, so the service can be exercised without a network.
+package llmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Request is one chat completion the fake received.
+type Request struct {
+	Model       string
+	Prompt      string
+	Stream      bool
+	Temperature float64
+	MaxTokens   int
+}
+
+// Reply is the fake's answer to a request: Content as the completion, or a
+// Status other than 200 with Content as the error body.
+type Reply struct {
+	Status  int
+	Content string
+}
+
+// Responder decides the reply to each request.
+type Responder func(req Request) Reply
+
+// Script answers with replies in order and fails every request after the
+// last, so a change that makes more calls than expected shows up.
+func Script(replies ...Reply) Responder {
+	var mu sync.Mutex
+	next := 0
+	return func(Request) Reply {
+		mu.Lock()
+		defer mu.Unlock()
+		if next >= len(replies) {
+			return Reply{Status: http.StatusInternalServerError, Content: "llmtest: script exhausted"}
+		}
+		next++
+		return replies[next-1]
+	}
+}
+
+// Text answers with a completion of content.
+func Text(content string) Reply {
+	return Reply{Status: http.StatusOK, Content: content}
+}
+
+// Server is a running fake chat completions API. Its URL is the base URL to
+// configure, e.g. DEEPSEEK_BASE_URL.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	respond  Responder
+	requests []Request
+}
+
+// NewServer starts a fake answering with respond.
+func NewServer(respond Responder) *Server {
+	s := &Server{respond: respond}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Reset forgets the requests received so far and answers later ones with
+// respond.
+func (s *Server) Reset(respond Responder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.respond = respond
+	s.requests = nil
+}
+
+// Requests lists the requests received, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/chat/completions") {
+		http.NotFound(w, r)
+		return
+	}
+
+	var body struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+		Stream      bool    `json:"stream"`
+		Temperature float64 `json:"temperature"`
+		MaxTokens   int     `json:"max_tokens"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("llmtest: invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	req := Request{
+		Model:       body.Model,
+		Stream:      body.Stream,
+		Temperature: body.Temperature,
+		MaxTokens:   body.MaxTokens,
+	}
+	if len(body.Messages) > 0 {
+		req.Prompt = body.Messages[len(body.Messages)-1].Content
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	respond := s.respond
+	s.mu.Unlock()
+
+	reply := respond(req)
+	if reply.Status != 0 && reply.Status != http.StatusOK {
+		http.Error(w, reply.Content, reply.Status)
+		return
+	}
+	if req.Stream {
+		writeStream(w, reply.Content)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"choices": []any{
+			map[string]any{"message": map[string]string{"role": "assistant", "content": reply.Content}},
+		},
+	})
+}
+
+// writeStream sends content as server-sent chunks of a few words each,
+// ending with [DONE] as the real API does.
+func writeStream(w http.ResponseWriter, content string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	flusher, _ := w.(http.Flusher)
+	for _, chunk := range chunks(content, 4) {
+		data, _ := json.Marshal(map[string]any{
+			"choices": []any{map[string]any{"delta": map[string]string{"content": chunk}}},
+		})
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+// chunks splits text after every words spaces, keeping every character.
+func chunks(text string, words int) []string {
+	var parts []string
+	start, spaces := 0, 0
+	for i, r := range text {
+		if r != ' ' {
+			continue
+		}
+		if spaces++; spaces == words {
+			parts = append(parts, text[start:i+1])
+			start, spaces = i+1, 0
+		}
+	}
+	if start < len(text) {
+		parts = append(parts, text[start:])
+	}
+	return parts
+}
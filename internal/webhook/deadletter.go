@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var ErrNotFound = errors.New("dead-lettered delivery not found")
+
+// DeadLetterStore keeps deliveries that exhausted their attempts.
+type DeadLetterStore interface {
+	Add(delivery *Delivery) error
+	Get(id string) (*Delivery, error)
+	// List returns deliveries oldest first.
+	List() ([]*Delivery, error)
+	Remove(id string) error
+}
+
+// MemoryDeadLetterStore is lost on restart; use FileDeadLetterStore when
+// dead letters must survive a redeploy.
+type MemoryDeadLetterStore struct {
+	mu         sync.RWMutex
+	deliveries map[string]*Delivery
+}
+
+func NewMemoryDeadLetterStore() *MemoryDeadLetterStore {
+	return &MemoryDeadLetterStore{deliveries: make(map[string]*Delivery)}
+}
+
+func (s *MemoryDeadLetterStore) Add(delivery *Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *delivery
+	s.deliveries[delivery.ID] = &copied
+	return nil
+}
+
+func (s *MemoryDeadLetterStore) Get(id string) (*Delivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	delivery, ok := s.deliveries[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *delivery
+	return &copied, nil
+}
+
+func (s *MemoryDeadLetterStore) List() ([]*Delivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]*Delivery, 0, len(s.deliveries))
+	for _, delivery := range s.deliveries {
+		copied := *delivery
+		list = append(list, &copied)
+	}
+	sortByCreated(list)
+	return list, nil
+}
+
+func (s *MemoryDeadLetterStore) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.deliveries[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.deliveries, id)
+	return nil
+}
+
+// FileDeadLetterStore writes one JSON file per delivery into a directory.
+type FileDeadLetterStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewFileDeadLetterStore(dir string) (*FileDeadLetterStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating dead-letter directory: %w", err)
+	}
+	return &FileDeadLetterStore{dir: dir}, nil
+}
+
+func (s *FileDeadLetterStore) Add(delivery *Delivery) error {
+	data, err := json.MarshalIndent(delivery, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling delivery: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return os.WriteFile(s.path(delivery.ID), data, 0644)
+}
+
+func (s *FileDeadLetterStore) Get(id string) (*Delivery, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(s.path(id))
+}
+
+func (s *FileDeadLetterStore) List() ([]*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*Delivery, 0, len(paths))
+	for _, path := range paths {
+		delivery, err := s.read(path)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, delivery)
+	}
+	sortByCreated(list)
+	return list, nil
+}
+
+func (s *FileDeadLetterStore) Remove(id string) error {
+	if !validID(id) {
+		return ErrNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *FileDeadLetterStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *FileDeadLetterStore) read(path string) (*Delivery, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var delivery Delivery
+	if err := json.Unmarshal(data, &delivery); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", filepath.Base(path), err)
+	}
+	return &delivery, nil
+}
+
+// validID keeps request-supplied IDs from escaping the store directory.
+func validID(id string) bool {
+	return id != "" && !strings.ContainsAny(id, `/\.`)
+}
+
+func sortByCreated(list []*Delivery) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+}
@@ -0,0 +1,301 @@
+package webhook
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Emmanuella-codes/burnished-microservice/internal/utils"
+	"github.com/Emmanuella-codes/burnished-microservice/pkg/webhooksig"
+)
+
+var ErrStopped = errors.New("webhook dispatcher is stopped")
+
+// Delivery is one payload on its way to the webhook endpoint.
+type Delivery struct {
+	ID            string          `json:"id"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"lastError,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	LastAttemptAt time.Time       `json:"lastAttemptAt,omitempty"`
+}
+
+type pendingRetry struct {
+	timer    *time.Timer
+	delivery *Delivery
+}
+
+type Options struct {
+	URL string
+	// Secrets sign every payload; list the new secret first and keep the old
+	// one alongside it until receivers have switched over.
+	Secrets     []string
+	Workers     int
+	QueueSize   int
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Dispatcher posts payloads to the webhook URL in the background, retrying
+// failures with exponential backoff. Deliveries that run out of attempts are
+// parked in the dead-letter store for inspection and redelivery.
+type Dispatcher struct {
+	opts        Options
+	client      *http.Client
+	deadLetters DeadLetterStore
+	queue       chan *Delivery
+
+	mu      sync.Mutex
+	stopped bool
+	retries map[string]*pendingRetry
+
+	wg   sync.WaitGroup
+	stop chan struct{}
+}
+
+func NewDispatcher(opts Options, client *http.Client, deadLetters DeadLetterStore) *Dispatcher {
+	if opts.Workers <= 0 {
+		opts.Workers = 2
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 100
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+	d := &Dispatcher{
+		opts:        opts,
+		client:      client,
+		deadLetters: deadLetters,
+		queue:       make(chan *Delivery, opts.QueueSize),
+		retries:     make(map[string]*pendingRetry),
+		stop:        make(chan struct{}),
+	}
+	for i := 0; i < opts.Workers; i++ {
+		d.wg.Add(1)
+		go d.worker()
+	}
+	return d
+}
+
+// Enqueue schedules payload for delivery and returns immediately.
+func (d *Dispatcher) Enqueue(payload []byte) (*Delivery, error) {
+	id, err := newDeliveryID()
+	if err != nil {
+		return nil, fmt.Errorf("generating delivery ID: %w", err)
+	}
+	delivery := &Delivery{
+		ID:        id,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	}
+	if err := d.push(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Redeliver moves a dead-lettered delivery back onto the queue with a fresh
+// attempt budget.
+func (d *Dispatcher) Redeliver(id string) (*Delivery, error) {
+	delivery, err := d.deadLetters.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	// claim the entry before queueing it, so concurrent redeliveries of one
+	// ID send it once; the others get ErrNotFound
+	if err := d.deadLetters.Remove(id); err != nil {
+		return nil, err
+	}
+	buried := *delivery
+	delivery.Attempts = 0
+	delivery.LastError = ""
+	if err := d.push(delivery); err != nil {
+		if addErr := d.deadLetters.Add(&buried); addErr != nil {
+			utils.LogError("Failed to restore webhook to dead letters", addErr, "deliveryID", id)
+		}
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (d *Dispatcher) DeadLetters() DeadLetterStore {
+	return d.deadLetters
+}
+
+// Stop waits for in-flight attempts and dead-letters everything still
+// queued or waiting for a retry, so no payload is lost silently.
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+	d.stopped = true
+	close(d.stop)
+	var waiting []*Delivery
+	for _, retry := range d.retries {
+		if retry.timer.Stop() {
+			waiting = append(waiting, retry.delivery)
+		}
+	}
+	d.mu.Unlock()
+
+	d.wg.Wait()
+
+	for _, delivery := range waiting {
+		d.bury(delivery, "")
+	}
+	for {
+		select {
+		case delivery := <-d.queue:
+			d.bury(delivery, "dispatcher stopped before delivery")
+		default:
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) push(delivery *Delivery) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return ErrStopped
+	}
+	select {
+	case d.queue <- delivery:
+		return nil
+	default:
+		return fmt.Errorf("webhook queue is full")
+	}
+}
+
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case delivery := <-d.queue:
+			d.attempt(delivery)
+		}
+	}
+}
+
+func (d *Dispatcher) attempt(delivery *Delivery) {
+	delivery.Attempts++
+	delivery.LastAttemptAt = time.Now()
+
+	retryable, err := d.send(delivery)
+	if err == nil {
+		utils.LogInfo("Webhook delivered", "deliveryID", delivery.ID, "attempts", delivery.Attempts)
+		return
+	}
+	delivery.LastError = err.Error()
+	utils.LogError("Webhook attempt failed", err, "deliveryID", delivery.ID, "attempt", delivery.Attempts)
+
+	if !retryable || delivery.Attempts >= d.opts.MaxAttempts {
+		d.bury(delivery, "")
+		return
+	}
+	d.scheduleRetry(delivery)
+}
+
+func (d *Dispatcher) scheduleRetry(delivery *Delivery) {
+	delay := d.backoff(delivery.Attempts)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		d.bury(delivery, "")
+		return
+	}
+	timer := time.AfterFunc(delay, func() {
+		d.mu.Lock()
+		delete(d.retries, delivery.ID)
+		d.mu.Unlock()
+		if err := d.push(delivery); err != nil {
+			d.bury(delivery, err.Error())
+		}
+	})
+	d.retries[delivery.ID] = &pendingRetry{timer: timer, delivery: delivery}
+	utils.LogInfo("Webhook retry scheduled", "deliveryID", delivery.ID, "delay", delay.String())
+}
+
+// backoff doubles the base delay per attempt, caps it and picks a random
+// point in the upper half so retries from a burst of failures spread out.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.opts.BaseDelay
+	for i := 1; i < attempt && delay < d.opts.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.opts.MaxDelay {
+		delay = d.opts.MaxDelay
+	}
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half)
+}
+
+func (d *Dispatcher) bury(delivery *Delivery, reason string) {
+	if reason != "" {
+		delivery.LastError = reason
+	}
+	if err := d.deadLetters.Add(delivery); err != nil {
+		utils.LogError("Failed to store dead-lettered webhook", err, "deliveryID", delivery.ID)
+		return
+	}
+	utils.LogWarn("Webhook moved to dead letters", "deliveryID", delivery.ID, "attempts", delivery.Attempts, "lastError", delivery.LastError)
+}
+
+// send makes a single delivery attempt and reports whether a failure is
+// worth retrying.
+func (d *Dispatcher) send(delivery *Delivery) (bool, error) {
+	req, err := http.NewRequest("POST", d.opts.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return false, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	// sign per attempt so retries carry a fresh timestamp
+	if len(d.opts.Secrets) > 0 {
+		req.Header.Set(webhooksig.SignatureHeader, webhooksig.Sign(delivery.Payload, time.Now(), d.opts.Secrets...))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Delivery", delivery.ID)
+	req.Header.Set("X-Webhook-Attempt", fmt.Sprint(delivery.Attempts))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := res.StatusCode >= 500 ||
+		res.StatusCode == http.StatusRequestTimeout ||
+		res.StatusCode == http.StatusTooManyRequests
+	return retryable, fmt.Errorf("webhook returned non-2xx status: %d", res.StatusCode)
+}
+
+func newDeliveryID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
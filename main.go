@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"github.com/joho/godotenv"
 
+	"github.com/Emmanuella-codes/burnished-microservice/internal/ai"
 	"github.com/Emmanuella-codes/burnished-microservice/internal/api"
 	"github.com/Emmanuella-codes/burnished-microservice/internal/config"
+	"github.com/Emmanuella-codes/burnished-microservice/internal/documents"
 	"github.com/Emmanuella-codes/burnished-microservice/internal/utils"
 )
 
@@ -21,7 +24,44 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	server := api.NewServer(cfg)
+	if cfg.UniOfficeLicenseKey != "" {
+		if err := documents.SetLicenseKey(cfg.UniOfficeLicenseKey); err != nil {
+			log.Fatalf("Failed to activate DOCX support: %v", err)
+		}
+	} else {
+		log.Printf("Warning: UNIOFFICE_LICENSE_KEY not set, DOCX input and output will fail")
+	}
+
+	if cfg.PDFFontsDir != "" {
+		if err := documents.LoadFallbackFonts(cfg.PDFFontsDir); err != nil {
+			log.Fatalf("Failed to load PDF fallback fonts: %v", err)
+		}
+	}
+
+	prompts, err := ai.NewPromptStore(cfg.PromptsDir)
+	if err != nil {
+		log.Fatalf("Failed to load prompt overrides: %v", err)
+	}
+	if cfg.PromptsDir != "" && cfg.PromptsReloadInterval > 0 {
+		go prompts.Watch(context.Background(), cfg.PromptsReloadInterval)
+	}
+
+	if err := prompts.ConfigureExperiments(cfg.PromptVariants); err != nil {
+		log.Fatalf("Invalid PROMPT_VARIANTS: %v", err)
+	}
+	for name, variants := range cfg.PromptVariants {
+		log.Printf("Running prompt experiment %s: %v", name, variants)
+	}
+
+	models, err := ai.NewRouter(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create LLM providers: %v", err)
+	}
+	for _, mode := range config.RoutedModes {
+		log.Printf("Using LLM models for %s: %s", mode, models.For(mode))
+	}
+
+	server := api.NewServer(cfg, models, prompts)
 	log.Printf("Starting server on port %s...", cfg.Port)
 	if err := server.Start(); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
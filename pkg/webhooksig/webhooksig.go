@@ -0,0 +1,153 @@
+// Package webhooksig signs and verifies Burnished webhook payloads.
+//
+// Every webhook request carries a header of the form
+//
+//	X-Burnished-Signature: t=1700000000,v1=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd
+//
+// where v1 is the hex HMAC-SHA256 of "<t>.<raw body>" keyed with the shared
+// webhook secret. While a secret is being rotated the header holds one v1
+// entry per active secret, so receivers can accept either.
+//
+// Receivers should verify before parsing the body:
+//
+//	body, err := webhooksig.VerifyRequest(r, webhooksig.DefaultTolerance, os.Getenv("WEBHOOK_SECRET"))
+//	if err != nil {
+//		http.Error(w, "invalid signature", http.StatusUnauthorized)
+//		return
+//	}
+package webhooksig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Burnished-Signature"
+
+	// DefaultTolerance is how far a signature timestamp may drift from the
+	// receiver's clock before the request is treated as a replay.
+	DefaultTolerance = 5 * time.Minute
+
+	schemeV1 = "v1"
+)
+
+var (
+	ErrMissingHeader     = errors.New("webhooksig: missing signature header")
+	ErrInvalidHeader     = errors.New("webhooksig: malformed signature header")
+	ErrTimestampExpired  = errors.New("webhooksig: timestamp outside tolerance")
+	ErrSignatureMismatch = errors.New("webhooksig: no matching signature")
+	ErrNoSecrets         = errors.New("webhooksig: no secrets configured")
+)
+
+// Sign returns the signature header value for body at timestamp, with one
+// v1 entry per non-empty secret.
+func Sign(body []byte, timestamp time.Time, secrets ...string) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	parts := []string{"t=" + ts}
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		parts = append(parts, schemeV1+"="+hex.EncodeToString(computeMAC(ts, body, secret)))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Verify checks header against body. It succeeds when the timestamp is within
+// tolerance of now and any v1 signature matches any of the secrets. A
+// tolerance of zero disables the timestamp check.
+func Verify(body []byte, header string, tolerance time.Duration, secrets ...string) error {
+	return verifyAt(body, header, tolerance, time.Now(), secrets)
+}
+
+// VerifyRequest reads r's body and verifies it against the signature header.
+// The body is returned so the caller can decode it; r.Body is consumed.
+func VerifyRequest(r *http.Request, tolerance time.Duration, secrets ...string) ([]byte, error) {
+	header := r.Header.Get(SignatureHeader)
+	if header == "" {
+		return nil, ErrMissingHeader
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("webhooksig: reading body: %w", err)
+	}
+	if err := Verify(body, header, tolerance, secrets...); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func verifyAt(body []byte, header string, tolerance time.Duration, now time.Time, secrets []string) error {
+	if header == "" {
+		return ErrMissingHeader
+	}
+
+	var ts string
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrInvalidHeader
+		}
+		switch key {
+		case "t":
+			ts = value
+		case schemeV1:
+			sig, err := hex.DecodeString(value)
+			if err != nil {
+				return ErrInvalidHeader
+			}
+			signatures = append(signatures, sig)
+		}
+		// unknown schemes are ignored so newer senders stay compatible
+	}
+	if ts == "" || len(signatures) == 0 {
+		return ErrInvalidHeader
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidHeader
+	}
+	if tolerance > 0 {
+		drift := now.Sub(time.Unix(unix, 0))
+		if drift > tolerance || drift < -tolerance {
+			return ErrTimestampExpired
+		}
+	}
+
+	checked := false
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		checked = true
+		expected := computeMAC(ts, body, secret)
+		for _, sig := range signatures {
+			if hmac.Equal(expected, sig) {
+				return nil
+			}
+		}
+	}
+	if !checked {
+		return ErrNoSecrets
+	}
+	return ErrSignatureMismatch
+}
+
+func computeMAC(ts string, body []byte, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,155 @@
+package webhooksig
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	body    = []byte(`{"documentID":"d1","status":"completed"}`)
+	signed  = time.Unix(1700000000, 0)
+	current = "whsec_current"
+	old     = "whsec_old"
+)
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	header := Sign(body, signed, current)
+	if !strings.HasPrefix(header, "t=1700000000,v1=") {
+		t.Fatalf("header = %q, want t= then one v1 entry", header)
+	}
+	if err := verifyAt(body, header, DefaultTolerance, signed, []string{current}); err != nil {
+		t.Fatalf("verifyAt: %v", err)
+	}
+}
+
+func TestSignSkipsEmptySecrets(t *testing.T) {
+	header := Sign(body, signed, "", current, "")
+	if n := strings.Count(header, "v1="); n != 1 {
+		t.Fatalf("header = %q has %d v1 entries, want 1", header, n)
+	}
+}
+
+func TestVerifyRotation(t *testing.T) {
+	tests := []struct {
+		name    string
+		signing []string
+		holding []string
+	}{
+		{"receiver still on old secret", []string{current, old}, []string{old}},
+		{"receiver already on new secret", []string{current, old}, []string{current}},
+		{"sender still on old secret", []string{old}, []string{current, old}},
+		{"empty secret alongside", []string{current}, []string{"", current}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := Sign(body, signed, tt.signing...)
+			if err := verifyAt(body, header, DefaultTolerance, signed, tt.holding); err != nil {
+				t.Errorf("verifyAt: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	valid := Sign(body, signed, current)
+	sig := strings.TrimPrefix(valid, "t=1700000000,")
+
+	tests := []struct {
+		name    string
+		body    []byte
+		header  string
+		now     time.Time
+		secrets []string
+		want    error
+	}{
+		{"tampered body", []byte(`{"documentID":"d1","status":"failed"}`), valid, signed, []string{current}, ErrSignatureMismatch},
+		{"wrong secret", body, valid, signed, []string{"whsec_other"}, ErrSignatureMismatch},
+		{"retired secret", body, Sign(body, signed, old), signed, []string{current}, ErrSignatureMismatch},
+		{"timestamp changed", body, "t=1700000001," + sig, signed, []string{current}, ErrSignatureMismatch},
+		{"too old", body, valid, signed.Add(DefaultTolerance + time.Second), []string{current}, ErrTimestampExpired},
+		{"too far ahead", body, valid, signed.Add(-DefaultTolerance - time.Second), []string{current}, ErrTimestampExpired},
+		{"missing header", body, "", signed, []string{current}, ErrMissingHeader},
+		{"no timestamp", body, sig, signed, []string{current}, ErrInvalidHeader},
+		{"no signature", body, "t=1700000000", signed, []string{current}, ErrInvalidHeader},
+		{"only unknown schemes", body, "t=1700000000,v0=abcd", signed, []string{current}, ErrInvalidHeader},
+		{"entry without =", body, valid + ",v1", signed, []string{current}, ErrInvalidHeader},
+		{"signature not hex", body, "t=1700000000,v1=zz", signed, []string{current}, ErrInvalidHeader},
+		{"timestamp not a number", body, "t=soon," + sig, signed, []string{current}, ErrInvalidHeader},
+		{"no secrets", body, valid, signed, nil, ErrNoSecrets},
+		{"only empty secrets", body, valid, signed, []string{""}, ErrNoSecrets},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyAt(tt.body, tt.header, DefaultTolerance, tt.now, tt.secrets)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("verifyAt = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyTolerance(t *testing.T) {
+	header := Sign(body, signed, current)
+	tests := []struct {
+		name      string
+		now       time.Time
+		tolerance time.Duration
+		want      error
+	}{
+		{"at the edge", signed.Add(DefaultTolerance), DefaultTolerance, nil},
+		{"at the edge ahead", signed.Add(-DefaultTolerance), DefaultTolerance, nil},
+		{"past a short tolerance", signed.Add(time.Minute + time.Second), time.Minute, ErrTimestampExpired},
+		{"zero disables the check", signed.Add(24 * time.Hour), 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyAt(body, header, tt.tolerance, tt.now, []string{current})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("verifyAt = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyIgnoresUnknownSchemes(t *testing.T) {
+	header := Sign(body, signed, current) + ",v2=deadbeef"
+	if err := verifyAt(body, header, DefaultTolerance, signed, []string{current}); err != nil {
+		t.Fatalf("verifyAt: %v", err)
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/webhook", strings.NewReader(string(body)))
+		r.Header.Set(SignatureHeader, Sign(body, time.Now(), current))
+		got, err := VerifyRequest(r, DefaultTolerance, current)
+		if err != nil {
+			t.Fatalf("VerifyRequest: %v", err)
+		}
+		if string(got) != string(body) {
+			t.Errorf("body = %q, want %q", got, body)
+		}
+	})
+
+	t.Run("missing header leaves the body unread", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/webhook", strings.NewReader(string(body)))
+		if _, err := VerifyRequest(r, DefaultTolerance, current); !errors.Is(err, ErrMissingHeader) {
+			t.Fatalf("VerifyRequest = %v, want %v", err, ErrMissingHeader)
+		}
+		if rest, _ := io.ReadAll(r.Body); string(rest) != string(body) {
+			t.Errorf("body was consumed")
+		}
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/webhook", strings.NewReader(`{"status":"failed"}`))
+		r.Header.Set(SignatureHeader, Sign(body, time.Now(), current))
+		if _, err := VerifyRequest(r, DefaultTolerance, current); !errors.Is(err, ErrSignatureMismatch) {
+			t.Fatalf("VerifyRequest = %v, want %v", err, ErrSignatureMismatch)
+		}
+	})
+}